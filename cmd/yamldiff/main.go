@@ -2,14 +2,17 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
+	"strings"
 
 	"github.com/alecthomas/kong"
-	"github.com/fatih/color"
 	"github.com/tyuhara/yamldiff/internal/config"
 	"github.com/tyuhara/yamldiff/internal/diff"
+	"github.com/tyuhara/yamldiff/internal/git"
 	"github.com/tyuhara/yamldiff/internal/github"
 	"github.com/tyuhara/yamldiff/internal/parser"
 )
@@ -29,24 +32,48 @@ type CLI struct {
 }
 
 type CompareCmd struct {
-	File1      string `arg:"" help:"First YAML file to compare." type:"existingfile"`
-	File2      string `arg:"" help:"Second YAML file to compare." type:"existingfile"`
-	Key        string `help:"YAML path to use as document identifier." default:"metadata.name"`
-	ShowCounts bool   `short:"c" help:"Show summary counts only."`
-	Verbose    bool   `short:"v" help:"Show verbose output with full document content."`
-	NoColor    bool   `help:"Disable color output."`
+	File1               string            `arg:"" help:"First YAML file, directory or glob (e.g. 'old/**/*.yaml') to compare, - for stdin, or a git range (main..HEAD) followed by -- PATH."`
+	File2               string            `arg:"" optional:"" help:"Second YAML file, directory or glob to compare, or - for stdin."`
+	GitBase             string            `help:"Compare PATH at this git revision (e.g. origin/main) against the working tree."`
+	GitHead             string            `help:"Read the new side from this git revision instead of the working tree (with --git-base)."`
+	Key                 string            `help:"Document identifier: a preset (kubernetes), a YAML path, comma-separated paths, or a Go template." default:"kubernetes"`
+	ListKey             map[string]string `help:"Field used to match elements of a list (path=field, e.g. containers=name)."`
+	Ignore              []string          `help:"Field path pattern to ignore (e.g. status.**, metadata.annotations[\"kubectl.kubernetes.io/*\"]). Repeatable."`
+	ShowCounts          bool              `short:"c" help:"Show summary counts only."`
+	Verbose             bool              `short:"v" help:"Show verbose output with full document content."`
+	NoColor             bool              `help:"Disable color output."`
+	Positions           bool              `short:"p" help:"Show file:line:column of every document and change."`
+	StrictKeys          bool              `help:"Fail when documents in one input share an identifier."`
+	KeepEmpty           bool              `help:"Compare empty documents (a bare ---) by position instead of skipping them."`
+	DetectRenames       bool              `help:"Report deleted and added documents with similar content as renames."`
+	RenameThreshold     float64           `help:"Similarity from 0 to 1 needed to pair a rename (default 0.5)."`
+	FlattenLists        bool              `help:"Expand Kubernetes List documents (kind List, PodList, ...) into their items before matching."`
+	InputFormat         string            `help:"Input format: auto (by file extension), yaml, json, jsonl or toml. Use old,new (e.g. yaml,json) to set each side." default:"auto"`
+	Output              string            `short:"o" help:"Output format (text, json)." enum:"text,json" default:"text"`
+	Format              string            `help:"How to show modified documents: flat (one line per field), unified (line diff hunks) or side-by-side." enum:"flat,unified,side-by-side" default:"flat"`
+	CompareMode         string            `help:"How values are compared: strict (a type change such as \"80\" → 80 is a change) or loose (numerically equal ints and floats are equal)." enum:"strict,loose" default:"strict"`
+	FormattingSensitive bool              `help:"Also report quoting style, indentation and comment changes; documents that only differ in formatting are listed as reformatted."`
+	DiffComments        bool              `help:"Report changed head, line and foot comments of fields; comment-only changes don't count as modifications for labels."`
+	CollapseAliases     bool              `help:"Report a change to an anchored node (&name) once at the anchor, listing the aliases (*name, <<: *name) it also affects."`
+	SortChanges         string            `help:"Order of the changes of a document: document (key order of the new file) or alphabetical." enum:"document,alphabetical" default:"document"`
+	Context             int               `help:"Unchanged lines around each hunk with --format unified or side-by-side." default:"3"`
+	Width               int               `help:"Total width of --format side-by-side (default: terminal width)."`
+	WordDiff            bool              `help:"Highlight changed words of replaced lines in line diffs and multi-line strings ([-old-]{+new+} without colour)."`
 
 	// GitHub integration (legacy flags)
 	GithubLabel    bool   `help:"Add GitHub label based on diff results."`
 	GithubRepo     string `help:"GitHub repository (owner/repo). Required with --github-label."`
 	GithubPR       int    `help:"GitHub PR number. Required with --github-label."`
 	GithubToken    string `help:"GitHub token (or use GITHUB_TOKEN env var)."`
+	GithubAPIURL   string `name:"github-api-url" help:"GitHub REST API base URL for GitHub Enterprise Server (or use GITHUB_API_URL env var)."`
 	ChangesLabel   string `help:"Label to add when changes are found." default:"config-sync/changes"`
 	NoChangesLabel string `help:"Label to add when no changes are found." default:"config-sync/no-changes"`
 
 	// Config file (tfcmt-style)
 	Config      string            `help:"Path to yamldiff.yaml config file." type:"existingfile"`
 	PostComment bool              `help:"Post comment to GitHub PR (requires --config)."`
+	CommentMode string            `help:"What to do with comments from previous runs: create, update, minimize or delete (overrides config)." enum:",create,update,minimize,delete" default:""`
+	Target      string            `help:"Target name that keeps comments of several yamldiff jobs on one PR apart (overrides config)."`
 	Link        string            `help:"CI build link to include in comment."`
 	Var         map[string]string `help:"Variables to pass to template (key=value)."`
 }
@@ -69,58 +96,109 @@ func main() {
 }
 
 func (c *CompareCmd) Run(cli *CLI) error {
-	// Disable color if requested
-	if c.NoColor {
-		color.NoColor = true
+	// Load config file
+	var cfg *config.Config
+	if c.Config != "" {
+		var err error
+		cfg, err = config.LoadConfig(c.Config)
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
 	}
 
-	// Parse both files
-	docs1, err := parser.ParseMultiDocYAML(c.File1)
+	// Parse both inputs
+	docs1, docs2, err := c.loadInputs()
 	if err != nil {
-		return fmt.Errorf("error parsing %s: %w", c.File1, err)
+		return err
+	}
+	if c.FlattenLists {
+		if docs1, err = parser.FlattenLists(docs1); err != nil {
+			return err
+		}
+		if docs2, err = parser.FlattenLists(docs2); err != nil {
+			return err
+		}
 	}
 
-	docs2, err := parser.ParseMultiDocYAML(c.File2)
+	identifier, err := parser.ParseIdentifier(c.Key)
 	if err != nil {
-		return fmt.Errorf("error parsing %s: %w", c.File2, err)
+		return fmt.Errorf("invalid --key: %w", err)
+	}
+
+	ignorePatterns := c.Ignore
+	if cfg != nil {
+		ignorePatterns = append(ignorePatterns, cfg.YAMLDiff.Compare.Ignore...)
+	}
+	ignore, err := diff.ParseIgnoreRules(ignorePatterns)
+	if err != nil {
+		return err
+	}
+
+	detectRenames, renameThreshold := c.DetectRenames, c.RenameThreshold
+	if cfg != nil {
+		detectRenames = detectRenames || cfg.YAMLDiff.Compare.DetectRenames
+		if renameThreshold == 0 {
+			renameThreshold = cfg.YAMLDiff.Compare.RenameThreshold
+		}
+	}
+	if renameThreshold < 0 || renameThreshold > 1 {
+		return fmt.Errorf("invalid rename threshold %v (expected 0 to 1)", renameThreshold)
 	}
 
 	// Create diff engine
-	engine := diff.NewEngine(c.Key)
+	engine := diff.NewEngine(identifier, diff.Options{
+		ListKeys:            c.ListKey,
+		Ignore:              ignore,
+		KeepEmpty:           c.KeepEmpty,
+		DetectRenames:       detectRenames,
+		RenameThreshold:     renameThreshold,
+		ChangeOrder:         diff.ChangeOrder(c.SortChanges),
+		Comparison:          diff.ValueComparison(c.CompareMode),
+		FormattingSensitive: c.FormattingSensitive,
+		DiffComments:        c.DiffComments,
+		CollapseAliases:     c.CollapseAliases,
+	})
 
 	// Compare documents
 	result := engine.Compare(docs1, docs2)
 
-	// Capture detailed output for comment/template
+	// Report documents that shadow each other
+	for _, dup := range result.Duplicates {
+		if c.StrictKeys {
+			fmt.Fprintf(os.Stderr, "error: %s\n", dup)
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", dup)
+		}
+	}
+	if c.StrictKeys && len(result.Duplicates) > 0 {
+		return fmt.Errorf("%d duplicate document identifier(s) found (--strict-keys)", len(result.Duplicates))
+	}
+
+	// Capture detailed output for comment/template (never coloured)
 	var detailsBuf bytes.Buffer
 	if c.Verbose {
-		// Use verbose output for details
-		oldStdout := os.Stdout
-		r, w, _ := os.Pipe()
-		os.Stdout = w
-
-		result.Print(true)
-
-		w.Close()
-		os.Stdout = oldStdout
-
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(r)
-		detailsBuf = *buf
+		details := &diff.TextRenderer{
+			Verbose:   true,
+			Positions: c.Positions,
+			Format:    diff.Format(c.Format),
+			Context:   c.Context,
+			WordDiff:  c.WordDiff,
+		}
+		if err := details.Render(&detailsBuf, result); err != nil {
+			return fmt.Errorf("error rendering details: %w", err)
+		}
 	}
 
 	// Print results to stdout (unless only posting comment)
 	if !c.PostComment || c.Config == "" {
-		if c.ShowCounts {
-			result.PrintSummary()
-		} else {
-			result.Print(c.Verbose)
+		if err := c.renderer(os.Stdout).Render(os.Stdout, result); err != nil {
+			return fmt.Errorf("error writing output: %w", err)
 		}
 	}
 
 	// Handle config file-based GitHub integration
-	if c.Config != "" {
-		if err := c.handleConfigBasedIntegration(result, detailsBuf.String()); err != nil {
+	if cfg != nil {
+		if err := c.handleConfigBasedIntegration(cfg, result, detailsBuf.String()); err != nil {
 			return err
 		}
 	} else if c.GithubLabel {
@@ -138,13 +216,128 @@ func (c *CompareCmd) Run(cli *CLI) error {
 	return nil
 }
 
-func (c *CompareCmd) handleConfigBasedIntegration(result *diff.Result, details string) error {
-	// Load config file
-	cfg, err := config.LoadConfig(c.Config)
+// loadInputs parses the old and new documents from files, directories and
+// globs or from git revisions
+func (c *CompareCmd) loadInputs() ([]parser.Document, []parser.Document, error) {
+	format1, format2, err := c.inputFormats()
 	if err != nil {
-		return fmt.Errorf("error loading config: %w", err)
+		return nil, nil, err
 	}
 
+	base, head := c.GitBase, c.GitHead
+	path := c.File1
+	switch {
+	case base != "":
+		if c.File2 != "" {
+			return nil, nil, fmt.Errorf("--git-base takes a single path")
+		}
+	case head != "":
+		return nil, nil, fmt.Errorf("--git-head requires --git-base")
+	case c.File2 == "":
+		return nil, nil, fmt.Errorf("expected two inputs, a git range and a path, or --git-base REV PATH")
+	case c.File1 == parser.Stdin && c.File2 == parser.Stdin:
+		return nil, nil, fmt.Errorf("only one input can be read from stdin")
+	default:
+		if _, err := os.Stat(c.File1); err != nil {
+			if b, h, ok := git.ParseRange(c.File1); ok {
+				base, head, path = b, h, c.File2
+			}
+		}
+	}
+
+	if base == "" {
+		docs1, err := parser.ParsePath(c.File1, format1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing %s: %w", c.File1, err)
+		}
+		docs2, err := parser.ParsePath(c.File2, format2)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing %s: %w", c.File2, err)
+		}
+		return docs1, docs2, nil
+	}
+
+	// A path that only exists on one side was added or deleted, and is
+	// compared with an empty input. Errors from git.ParsePath name the
+	// revision already.
+	docs1, err1 := git.ParsePath(base, path, format1)
+	var docs2 []parser.Document
+	var err2 error
+	newSide := head
+	if head != "" {
+		docs2, err2 = git.ParsePath(head, path, format2)
+	} else {
+		newSide = "the working tree"
+		docs2, err2 = parser.ParsePath(path, format2)
+		if err2 != nil && !errors.Is(err2, fs.ErrNotExist) {
+			err2 = fmt.Errorf("error parsing %s: %w", path, err2)
+		}
+	}
+
+	missing1, missing2 := isNotExist(err1), isNotExist(err2)
+	switch {
+	case missing1 && missing2:
+		return nil, nil, fmt.Errorf("%s exists neither at %s nor in %s", path, base, newSide)
+	case err1 != nil && !missing1:
+		return nil, nil, err1
+	case err2 != nil && !missing2:
+		return nil, nil, err2
+	}
+	return docs1, docs2, nil
+}
+
+// isNotExist reports whether err means the path is missing at a revision
+// or in the working tree
+func isNotExist(err error) bool {
+	var notExist *git.NotExistError
+	return errors.As(err, &notExist) || errors.Is(err, fs.ErrNotExist)
+}
+
+// inputFormats returns the formats of the old and new inputs from
+// --input-format, which names one format for both or old,new
+func (c *CompareCmd) inputFormats() (parser.Format, parser.Format, error) {
+	oldName, newName, ok := strings.Cut(c.InputFormat, ",")
+	if !ok {
+		newName = oldName
+	}
+	format1, err := parser.ParseFormat(oldName)
+	if err != nil {
+		return "", "", err
+	}
+	format2, err := parser.ParseFormat(newName)
+	if err != nil {
+		return "", "", err
+	}
+	return format1, format2, nil
+}
+
+// renderer returns the renderer selected by the output flags for w
+func (c *CompareCmd) renderer(w io.Writer) diff.Renderer {
+	useColor := !c.NoColor && diff.ColorEnabled(w)
+
+	switch {
+	case c.Output == "json":
+		return diff.JSONRenderer{}
+	case c.ShowCounts:
+		return &diff.SummaryRenderer{Color: useColor}
+	default:
+		width := c.Width
+		if width == 0 {
+			width = diff.TerminalWidth(w)
+		}
+		return &diff.TextRenderer{
+			Verbose:   c.Verbose,
+			Positions: c.Positions,
+			Color:     useColor,
+			Format:    diff.Format(c.Format),
+			Context:   c.Context,
+			Width:     width,
+			WordDiff:  c.WordDiff,
+		}
+	}
+}
+
+func (c *CompareCmd) handleConfigBasedIntegration(cfg *config.Config, result *diff.Result, details string) error {
 	// Determine repo and PR number
 	repo := cfg.GetRepoFullName()
 	if c.GithubRepo != "" {
@@ -168,6 +361,7 @@ func (c *CompareCmd) handleConfigBasedIntegration(result *diff.Result, details s
 		return fmt.Errorf("GitHub token not provided (use --github-token or GITHUB_TOKEN env var)")
 	}
 
+	client := github.NewClient(token, c.apiURL(cfg))
 	compareConfig := cfg.YAMLDiff.Compare
 
 	// Post comment if requested and template is configured
@@ -187,19 +381,30 @@ func (c *CompareCmd) handleConfigBasedIntegration(result *diff.Result, details s
 			return fmt.Errorf("error rendering template: %w", err)
 		}
 
+		modeName := compareConfig.Comment.Mode
+		if c.CommentMode != "" {
+			modeName = c.CommentMode
+		}
+		mode, err := github.ParseCommentMode(modeName)
+		if err != nil {
+			return err
+		}
+		target := compareConfig.Comment.Target
+		if c.Target != "" {
+			target = c.Target
+		}
+
 		// Post comment
-		if err := github.PostComment(repo, prNumber, commentBody); err != nil {
+		if err := client.PublishComment(repo, prNumber, commentBody, mode, target); err != nil {
 			return fmt.Errorf("error posting comment: %w", err)
 		}
 	}
 
-	// Add label if not disabled
+	// Reconcile labels if not disabled
 	if !compareConfig.DisableLabel {
-		labels := compareConfig.GetLabels(len(result.Added), len(result.Deleted), len(result.Modified))
-		if len(labels) > 0 {
-			if err := github.AddLabels(repo, prNumber, labels); err != nil {
-				return fmt.Errorf("error adding labels: %w", err)
-			}
+		labels := compareConfig.GetLabels(len(result.Added), len(result.Deleted), len(result.Modified), len(result.Renamed))
+		if err := client.ReconcileLabels(repo, prNumber, labels, compareConfig.ManagedLabels()); err != nil {
+			return fmt.Errorf("error updating labels: %w", err)
 		}
 	}
 
@@ -226,24 +431,24 @@ func (c *CompareCmd) applyGithubLabel(result *diff.Result) error {
 
 	// Determine which label to apply
 	label := c.NoChangesLabel
-	if result.HasDifferences() {
+	if result.HasContentChanges() {
 		label = c.ChangesLabel
 	}
 
-	// Apply label using gh CLI
-	return applyGithubLabelWithGH(c.GithubRepo, c.GithubPR, label)
+	// Swap the label when the result flips between changes and no changes
+	client := github.NewClient(token, c.apiURL(nil))
+	managed := []string{c.ChangesLabel, c.NoChangesLabel}
+	return client.ReconcileLabels(c.GithubRepo, c.GithubPR, []string{label}, managed)
 }
 
-func applyGithubLabelWithGH(repo string, prNumber int, label string) error {
-	cmd := exec.Command("gh", "pr", "edit", fmt.Sprintf("%d", prNumber),
-		"--repo", repo,
-		"--add-label", label)
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to add label: %w\nOutput: %s", err, string(output))
+// apiURL returns the GitHub API base URL from --github-api-url, the config
+// file or GITHUB_API_URL, in that order
+func (c *CompareCmd) apiURL(cfg *config.Config) string {
+	if c.GithubAPIURL != "" {
+		return c.GithubAPIURL
 	}
-
-	fmt.Fprintf(os.Stderr, "✓ Applied GitHub label: %s\n", label)
-	return nil
+	if cfg != nil && cfg.GitHubAPIURL != "" {
+		return cfg.GitHubAPIURL
+	}
+	return os.Getenv("GITHUB_API_URL")
 }
@@ -3,13 +3,15 @@ module github.com/tyuhara/yamldiff
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/alecthomas/kong v0.8.1
 	github.com/fatih/color v1.16.0
+	github.com/mattn/go-isatty v0.0.20
+	golang.org/x/term v0.14.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.14.0 // indirect
 )
@@ -9,9 +9,12 @@ import (
 
 // Config represents the yamldiff configuration
 type Config struct {
-	RepoOwner string         `yaml:"repo_owner"`
-	RepoName  string         `yaml:"repo_name"`
-	YAMLDiff  YAMLDiffConfig `yaml:"yamldiff"`
+	RepoOwner string `yaml:"repo_owner"`
+	RepoName  string `yaml:"repo_name"`
+	// GitHubAPIURL is the REST API base URL, for GitHub Enterprise Server
+	// (e.g. https://github.example.com/api/v3)
+	GitHubAPIURL string         `yaml:"github_api_url"`
+	YAMLDiff     YAMLDiffConfig `yaml:"yamldiff"`
 }
 
 // YAMLDiffConfig represents the yamldiff-specific configuration
@@ -21,13 +24,29 @@ type YAMLDiffConfig struct {
 
 // CompareConfig represents the compare command configuration
 type CompareConfig struct {
-	Template             string      `yaml:"template"`
-	WhenHasAdditions     LabelConfig `yaml:"when_has_additions"`
-	WhenHasDeletions     LabelConfig `yaml:"when_has_deletions"`
-	WhenHasModifications LabelConfig `yaml:"when_has_modifications"`
-	WhenNoChanges        LabelConfig `yaml:"when_no_changes"`
-	DisableComment       bool        `yaml:"disable_comment"`
-	DisableLabel         bool        `yaml:"disable_label"`
+	Template             string        `yaml:"template"`
+	WhenHasAdditions     LabelConfig   `yaml:"when_has_additions"`
+	WhenHasDeletions     LabelConfig   `yaml:"when_has_deletions"`
+	WhenHasModifications LabelConfig   `yaml:"when_has_modifications"`
+	WhenHasRenames       LabelConfig   `yaml:"when_has_renames"`
+	WhenNoChanges        LabelConfig   `yaml:"when_no_changes"`
+	Comment              CommentConfig `yaml:"comment"`
+	DisableComment       bool          `yaml:"disable_comment"`
+	DisableLabel         bool          `yaml:"disable_label"`
+	// Ignore lists field path patterns whose changes are not reported
+	Ignore []string `yaml:"ignore"`
+	// DetectRenames reports similar deleted and added documents as renames
+	DetectRenames bool `yaml:"detect_renames"`
+	// RenameThreshold is the similarity (0-1) needed for a rename
+	RenameThreshold float64 `yaml:"rename_threshold"`
+}
+
+// CommentConfig controls how comments from previous runs are handled
+type CommentConfig struct {
+	// Mode is one of create (default), update, minimize or delete
+	Mode string `yaml:"mode"`
+	// Target distinguishes comments of several yamldiff jobs on one PR
+	Target string `yaml:"target"`
 }
 
 // LabelConfig represents label configuration
@@ -59,17 +78,18 @@ func (c *Config) GetRepoFullName() string {
 }
 
 // GetLabels returns all applicable labels based on diff result
-// Labels are cumulative - if there are additions, deletions, and modifications,
-// all three labels will be returned
-func (c *CompareConfig) GetLabels(added, deleted, modified int) []string {
+// Labels are cumulative - if there are additions, deletions, modifications
+// and renames, all four labels will be returned
+func (c *CompareConfig) GetLabels(added, deleted, modified, renamed int) []string {
 	var labels []string
 
 	hasAdd := added > 0
 	hasDelete := deleted > 0
 	hasModify := modified > 0
+	hasRename := renamed > 0
 
 	// No changes at all
-	if !hasAdd && !hasDelete && !hasModify {
+	if !hasAdd && !hasDelete && !hasModify && !hasRename {
 		if c.WhenNoChanges.Label != "" {
 			labels = append(labels, c.WhenNoChanges.Label)
 		}
@@ -91,5 +111,30 @@ func (c *CompareConfig) GetLabels(added, deleted, modified int) []string {
 		labels = append(labels, c.WhenHasModifications.Label)
 	}
 
+	// Add label for renames
+	if hasRename && c.WhenHasRenames.Label != "" {
+		labels = append(labels, c.WhenHasRenames.Label)
+	}
+
+	return labels
+}
+
+// ManagedLabels returns every label the config can add. Labels in this set
+// that no longer apply to a PR are removed.
+func (c *CompareConfig) ManagedLabels() []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, cfg := range []LabelConfig{
+		c.WhenHasAdditions,
+		c.WhenHasDeletions,
+		c.WhenHasModifications,
+		c.WhenHasRenames,
+		c.WhenNoChanges,
+	} {
+		if cfg.Label != "" && !seen[cfg.Label] {
+			seen[cfg.Label] = true
+			labels = append(labels, cfg.Label)
+		}
+	}
 	return labels
 }
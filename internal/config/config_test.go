@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetLabels(t *testing.T) {
+	cfg := CompareConfig{
+		WhenHasAdditions:     LabelConfig{Label: "yaml/added"},
+		WhenHasDeletions:     LabelConfig{Label: "yaml/deleted"},
+		WhenHasModifications: LabelConfig{Label: "yaml/modified"},
+		WhenHasRenames:       LabelConfig{Label: "yaml/renamed"},
+		WhenNoChanges:        LabelConfig{Label: "yaml/no-changes"},
+	}
+	tests := []struct {
+		name                              string
+		added, deleted, modified, renamed int
+		want                              []string
+	}{
+		{"no changes", 0, 0, 0, 0, []string{"yaml/no-changes"}},
+		{"added", 2, 0, 0, 0, []string{"yaml/added"}},
+		{"deleted and modified", 0, 1, 3, 0, []string{"yaml/deleted", "yaml/modified"}},
+		{"renamed only", 0, 0, 0, 1, []string{"yaml/renamed"}},
+		{"everything", 1, 1, 1, 1, []string{"yaml/added", "yaml/deleted", "yaml/modified", "yaml/renamed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.GetLabels(tt.added, tt.deleted, tt.modified, tt.renamed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLabels() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := (&CompareConfig{}).GetLabels(0, 0, 0, 0); got != nil {
+		t.Errorf("GetLabels() without labels = %q, want none", got)
+	}
+	partial := CompareConfig{WhenHasAdditions: LabelConfig{Label: "yaml/added"}}
+	if got := partial.GetLabels(0, 1, 1, 1); got != nil {
+		t.Errorf("GetLabels() without matching labels = %q, want none", got)
+	}
+}
+
+func TestManagedLabels(t *testing.T) {
+	cfg := CompareConfig{
+		WhenHasAdditions:     LabelConfig{Label: "yaml"},
+		WhenHasDeletions:     LabelConfig{Label: "yaml"},
+		WhenHasModifications: LabelConfig{Label: "yaml/modified"},
+		WhenNoChanges:        LabelConfig{Label: "yaml/no-changes"},
+	}
+	want := []string{"yaml", "yaml/modified", "yaml/no-changes"}
+	if got := cfg.ManagedLabels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ManagedLabels() = %q, want %q", got, want)
+	}
+}
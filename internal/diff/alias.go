@@ -0,0 +1,189 @@
+package diff
+
+import (
+	"reflect"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// aliasTarget is where a change path leaves the document through an alias
+// or a merge key (<<) into an anchored node
+type aliasTarget struct {
+	anchor *yaml.Node
+	// suffix is the rest of the path below the anchored node
+	suffix Path
+}
+
+// foldAliases folds changes seen through an alias into the change of the
+// anchored node they come from. A change at an alias site is folded when
+// the same change is reported below the anchor definition, which then
+// lists the site in AliasSites. Changes that differ from the anchor's, e.g.
+// because the site replaced the alias, are kept where they are.
+func (e *Engine) foldAliases(changes []Change, oldDoc, newDoc parser.Document) []Change {
+	oldAnchors := e.anchorPaths(oldDoc.Node)
+	newAnchors := e.anchorPaths(newDoc.Node)
+	if len(oldAnchors) == 0 && len(newAnchors) == 0 {
+		return changes
+	}
+
+	byPath := make(map[string]int, len(changes))
+	for i, change := range changes {
+		byPath[change.Path.String()] = i
+	}
+
+	folded := make([]bool, len(changes))
+	for i, change := range changes {
+		target, anchor, ok := aliasedPath(change, oldDoc.Node, newDoc.Node, oldAnchors, newAnchors)
+		if !ok {
+			continue
+		}
+		j, ok := byPath[target.String()]
+		if !ok || j == i || !sameChange(changes[j], change) {
+			continue
+		}
+		changes[j].AliasSites = append(changes[j].AliasSites, AliasSite{Path: change.Path, Anchor: anchor})
+		folded[i] = true
+	}
+
+	kept := changes[:0]
+	for i, change := range changes {
+		if !folded[i] {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+// aliasedPath returns the path below the anchor definition that a change
+// at an alias site corresponds to. Both sides the change exists on must
+// reach the same anchor definition.
+func aliasedPath(c Change, oldRoot, newRoot *yaml.Node, oldAnchors, newAnchors map[*yaml.Node]Path) (Path, string, bool) {
+	var target Path
+	var anchor string
+	for _, side := range []struct {
+		root    *yaml.Node
+		anchors map[*yaml.Node]Path
+		used    bool
+	}{
+		{oldRoot, oldAnchors, c.Op != OpAdd},
+		{newRoot, newAnchors, c.Op != OpDelete},
+	} {
+		if !side.used {
+			continue
+		}
+		via, ok := throughAlias(side.root, c.Path)
+		if !ok {
+			return nil, "", false
+		}
+		anchorPath, ok := side.anchors[via.anchor]
+		if !ok {
+			return nil, "", false
+		}
+		path := append(append(Path{}, anchorPath...), via.suffix...)
+		if target != nil && (path.String() != target.String() || via.anchor.Anchor != anchor) {
+			return nil, "", false
+		}
+		target, anchor = path, via.anchor.Anchor
+	}
+	return target, anchor, target != nil
+}
+
+// throughAlias follows path from root and returns the first anchored node
+// it enters through an alias or a merge key, if the path continues below it
+func throughAlias(root *yaml.Node, path Path) (aliasTarget, bool) {
+	node := root
+	for i, seg := range path {
+		if node == nil {
+			return aliasTarget{}, false
+		}
+		if node.Kind == yaml.AliasNode {
+			return aliasTarget{anchor: node.Alias, suffix: path[i:]}, true
+		}
+		if seg.Kind == KeySegment && node.Kind == yaml.MappingNode && !hasOwnKey(node, seg.Key) {
+			if source := mergeSource(node, seg.Key); source != nil {
+				return aliasTarget{anchor: source, suffix: path[i:]}, true
+			}
+		}
+		node = child(node, seg)
+	}
+	return aliasTarget{}, false
+}
+
+// hasOwnKey reports whether a mapping sets key itself rather than through
+// a merge key
+func hasOwnKey(node *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k := node.Content[i]; !isMergeKey(k) && k.Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSource returns the anchored mapping a merge key of node brings key
+// in from
+func mergeSource(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isMergeKey(node.Content[i]) {
+			continue
+		}
+		merge := node.Content[i+1]
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, source := range sources {
+			if source.Kind != yaml.AliasNode {
+				continue
+			}
+			if k, _ := mappingEntry(source.Alias, key); k != nil {
+				return source.Alias
+			}
+		}
+	}
+	return nil
+}
+
+// anchorPaths returns the path of every anchored node of a document,
+// addressing keyed list elements the way compareSequences does
+func (e *Engine) anchorPaths(root *yaml.Node) map[*yaml.Node]Path {
+	paths := make(map[*yaml.Node]Path)
+	var walk func(path Path, node *yaml.Node)
+	walk = func(path Path, node *yaml.Node) {
+		if node == nil || node.Kind == yaml.AliasNode {
+			return
+		}
+		if node.Anchor != "" {
+			paths[node] = path
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if !isMergeKey(node.Content[i]) {
+					walk(path.Child(node.Content[i].Value), node.Content[i+1])
+				}
+			}
+		case yaml.SequenceNode:
+			var keys []string
+			field := e.listKey(path)
+			if field != "" {
+				keys = elementKeys(node, field)
+			}
+			for i, elem := range node.Content {
+				if len(keys) == len(node.Content) && len(keys) > 0 {
+					walk(path.Match(field, keys[i]), elem)
+				} else {
+					walk(path.Element(i), elem)
+				}
+			}
+		}
+	}
+	walk(nil, root)
+	return paths
+}
+
+// sameChange reports whether two changes do the same thing to their fields
+func sameChange(a, b Change) bool {
+	return a.Op == b.Op && reflect.DeepEqual(a.OldValue, b.OldValue) && reflect.DeepEqual(a.NewValue, b.NewValue)
+}
@@ -0,0 +1,365 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// Operation describes what happened to a single field
+type Operation string
+
+const (
+	// OpAdd means the field only exists in the new document
+	OpAdd Operation = "add"
+	// OpDelete means the field only exists in the old document
+	OpDelete Operation = "delete"
+	// OpModify means the field exists in both documents with different values
+	OpModify Operation = "modify"
+	// OpFormat means the field has the same value in both documents but is
+	// written differently (see FormattingKind)
+	OpFormat Operation = "format"
+	// OpComment means a comment attached to the field changed (see
+	// CommentPosition); the value is unchanged
+	OpComment Operation = "comment"
+)
+
+// ValueComparison selects how scalar values are compared
+type ValueComparison string
+
+const (
+	// CompareStrict treats values of different types as different, so "80"
+	// and 80 or 1 and 1.0 are reported as type changes
+	CompareStrict ValueComparison = "strict"
+	// CompareLoose is like CompareStrict but treats numerically equal ints
+	// and floats as equal
+	CompareLoose ValueComparison = "loose"
+)
+
+// SegmentKind tells how a PathSegment addresses its parent
+type SegmentKind int
+
+const (
+	// KeySegment addresses a mapping entry by key
+	KeySegment SegmentKind = iota
+	// IndexSegment addresses a sequence element by position
+	IndexSegment
+	// MatchSegment addresses a sequence element by the value of a merge key
+	MatchSegment
+)
+
+// PathSegment is a single step into a document
+type PathSegment struct {
+	Kind       SegmentKind
+	Key        string
+	Index      int
+	MatchField string
+	MatchValue string
+}
+
+// Path identifies a field inside a document
+type Path []PathSegment
+
+// String renders the path in dot notation, quoting keys that cannot be
+// written as plain dot segments (e.g. metadata.annotations["app.io/name"])
+// and addressing list elements as [0] or [name=app]. The document root is
+// rendered as ".".
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for i, seg := range p {
+		switch seg.Kind {
+		case IndexSegment:
+			fmt.Fprintf(&b, "[%d]", seg.Index)
+			continue
+		case MatchSegment:
+			value := seg.MatchValue
+			if needsQuoting(value) {
+				value = strconv.Quote(value)
+			}
+			fmt.Fprintf(&b, "[%s=%s]", seg.MatchField, value)
+			continue
+		}
+		if needsQuoting(seg.Key) {
+			b.WriteString("[" + strconv.Quote(seg.Key) + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(seg.Key)
+	}
+	return b.String()
+}
+
+// Child returns a copy of the path extended with a mapping key
+func (p Path) Child(key string) Path {
+	return p.append(PathSegment{Kind: KeySegment, Key: key})
+}
+
+// Element returns a copy of the path extended with a sequence index
+func (p Path) Element(index int) Path {
+	return p.append(PathSegment{Kind: IndexSegment, Index: index})
+}
+
+// Match returns a copy of the path extended with a keyed sequence element
+func (p Path) Match(field, value string) Path {
+	return p.append(PathSegment{Kind: MatchSegment, MatchField: field, MatchValue: value})
+}
+
+// Keys returns the mapping keys of the path, skipping sequence segments
+func (p Path) Keys() []string {
+	var keys []string
+	for _, seg := range p {
+		if seg.Kind == KeySegment {
+			keys = append(keys, seg.Key)
+		}
+	}
+	return keys
+}
+
+func (p Path) append(seg PathSegment) Path {
+	child := make(Path, len(p), len(p)+1)
+	copy(child, p)
+	return append(child, seg)
+}
+
+func needsQuoting(key string) bool {
+	return key == "" || strings.ContainsAny(key, ".[]\"' \t")
+}
+
+// AliasSite is a path that reaches a field through an alias (*name) or a
+// merge key (<<: *name) of the anchor name
+type AliasSite struct {
+	Path   Path
+	Anchor string
+}
+
+// Change is a single field-level difference between two documents
+type Change struct {
+	Op       Operation
+	Path     Path
+	OldValue interface{}
+	NewValue interface{}
+	OldType  string
+	NewType  string
+	// Formatting tells what an OpFormat change is about; OldValue and
+	// NewValue then describe the old and new formatting
+	Formatting FormattingKind
+	// Comment tells which comment of the field an OpComment change is
+	// about; OldValue and NewValue then hold the comment text, empty when
+	// there is none
+	Comment CommentPosition
+	// AliasSites lists, with CollapseAliases, the paths where the same
+	// change was seen through an alias of an anchored node and folded into
+	// this one
+	AliasSites []AliasSite
+	// OldPosition and NewPosition locate the field in the old and new
+	// files; they are zero on the side where the field does not exist
+	OldPosition parser.Position
+	NewPosition parser.Position
+}
+
+// String renders the change in the classic one-line format. A change of
+// type names both types, e.g. ~ port: "80" (string) → 80 (int).
+func (c Change) String() string {
+	switch {
+	case c.Op == OpAdd:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatValue(c.NewValue))
+	case c.Op == OpDelete:
+		return fmt.Sprintf("- %s: %s", c.Path, formatValue(c.OldValue))
+	case c.Op == OpComment && c.OldValue == "":
+		return fmt.Sprintf("# %s: %s comment added %s", c.Path, c.Comment, formatTypedValue(c.NewValue))
+	case c.Op == OpComment && c.NewValue == "":
+		return fmt.Sprintf("# %s: %s comment removed %s", c.Path, c.Comment, formatTypedValue(c.OldValue))
+	case c.Op == OpComment:
+		return fmt.Sprintf("# %s: %s comment %s → %s", c.Path, c.Comment, formatTypedValue(c.OldValue), formatTypedValue(c.NewValue))
+	case c.Op == OpFormat && c.Formatting == FormattingStyle:
+		return fmt.Sprintf("≈ %s: style %s → %s", c.Path, c.OldValue, c.NewValue)
+	case c.Op == OpFormat:
+		return fmt.Sprintf("≈ %s: %s → %s", c.Path, c.OldValue, c.NewValue)
+	case c.TypeChanged():
+		return fmt.Sprintf("~ %s: %s (%s) → %s (%s)", c.Path,
+			formatTypedValue(c.OldValue), c.OldType, formatTypedValue(c.NewValue), c.NewType)
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", c.Path, formatValue(c.OldValue), formatValue(c.NewValue))
+	}
+}
+
+// IsContent reports whether the change is to a value rather than to
+// formatting or comments
+func (c Change) IsContent() bool {
+	return c.Op != OpFormat && c.Op != OpComment
+}
+
+// TypeChanged reports whether a modified field changed its YAML type
+func (c Change) TypeChanged() bool {
+	return c.Op == OpModify && c.OldType != c.NewType
+}
+
+// formatValue formats a value for one-line output, writing nil as null
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatTypedValue is formatValue with strings quoted and whole floats
+// written with a fraction, so "80", 80 and 80.0 can be told apart
+func formatTypedValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		s := strconv.FormatFloat(val, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEnN") {
+			s += ".0"
+		}
+		return s
+	default:
+		return formatValue(v)
+	}
+}
+
+// ValueType returns the YAML type name of a decoded value
+func ValueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case time.Time:
+		return "timestamp"
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// CompareValues recursively compares two values and returns the changes
+// between them
+func (e *Engine) CompareValues(path Path, oldVal, newVal interface{}) []Change {
+	var changes []Change
+
+	oldMap, oldIsMap := oldVal.(map[string]interface{})
+	newMap, newIsMap := newVal.(map[string]interface{})
+	oldList, oldIsList := oldVal.([]interface{})
+	newList, newIsList := newVal.([]interface{})
+
+	if oldIsMap && newIsMap {
+		// Both are maps - recurse in a stable order
+		for _, key := range sortedMapKeys(oldMap, newMap) {
+			newPath := path.Child(key)
+
+			oldV, oldExists := oldMap[key]
+			newV, newExists := newMap[key]
+
+			if !oldExists && newExists {
+				changes = append(changes, added(newPath, newV))
+			} else if oldExists && !newExists {
+				changes = append(changes, deleted(newPath, oldV))
+			} else if oldExists && newExists {
+				changes = append(changes, e.CompareValues(newPath, oldV, newV)...)
+			}
+		}
+	} else if oldIsList && newIsList {
+		// Both are sequences - diff element by element
+		changes = append(changes, e.compareSequences(path, oldList, newList)...)
+	} else if !e.equalScalars(oldVal, newVal) {
+		changes = append(changes, Change{
+			Op:       OpModify,
+			Path:     path,
+			OldValue: oldVal,
+			NewValue: newVal,
+			OldType:  ValueType(oldVal),
+			NewType:  ValueType(newVal),
+		})
+	}
+
+	return changes
+}
+
+// equalScalars reports whether two values that are not both maps or both
+// lists are equal. Values of different types are never equal, except an
+// int and a float of exactly the same value with CompareLoose. NaN equals
+// NaN in both modes.
+func (e *Engine) equalScalars(a, b interface{}) bool {
+	if e.comparison == CompareLoose {
+		if i, ok := integer(a); ok {
+			if f, ok := b.(float64); ok {
+				return equalIntFloat(i, f)
+			}
+		}
+		if i, ok := integer(b); ok {
+			if f, ok := a.(float64); ok {
+				return equalIntFloat(i, f)
+			}
+		}
+	}
+	if ValueType(a) != ValueType(b) {
+		return false
+	}
+	if t, ok := a.(time.Time); ok {
+		return t.Equal(b.(time.Time))
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+// integer returns a decoded int as a big.Int
+func integer(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	default:
+		return nil, false
+	}
+}
+
+// equalIntFloat reports whether a float holds exactly the value of an int,
+// without the rounding of converting the int to a float64
+func equalIntFloat(i *big.Int, f float64) bool {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return false
+	}
+	fi, _ := new(big.Float).SetFloat64(f).Int(nil)
+	return fi.Cmp(i) == 0
+}
+
+func added(path Path, value interface{}) Change {
+	return Change{
+		Op:       OpAdd,
+		Path:     path,
+		NewValue: value,
+		NewType:  ValueType(value),
+	}
+}
+
+func deleted(path Path, value interface{}) Change {
+	return Change{
+		Op:       OpDelete,
+		Path:     path,
+		OldValue: value,
+		OldType:  ValueType(value),
+	}
+}
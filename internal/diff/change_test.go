@@ -0,0 +1,68 @@
+package diff
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEqualScalars(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		a, b   interface{}
+		strict bool
+		loose  bool
+	}{
+		{"same int", 80, 80, true, true},
+		{"different int", 80, 81, false, false},
+		{"int and uint64", 5, uint64(5), true, true},
+		{"int above 2^53", 9007199254740993, 9007199254740992, false, false},
+		{"uint64 above 2^63", uint64(18446744073709551615), uint64(18446744073709551614), false, false},
+		{"int and whole float", 1, 1.0, false, true},
+		{"float and int", 2.0, 2, false, true},
+		{"int and fractional float", 1, 1.5, false, false},
+		{"int above 2^53 and nearest float", 9007199254740993, 9007199254740992.0, false, false},
+		{"int at 2^53 and float", 9007199254740992, 9007199254740992.0, false, true},
+		{"uint64 and float", uint64(1 << 63), float64(1 << 63), false, true},
+		{"NaN", math.NaN(), math.NaN(), true, true},
+		{"int and NaN", 1, math.NaN(), false, false},
+		{"infinities", math.Inf(1), math.Inf(1), true, true},
+		{"opposite infinities", math.Inf(1), math.Inf(-1), false, false},
+		{"int and infinity", math.MaxInt64, math.Inf(1), false, false},
+		{"string and int", "80", 80, false, false},
+		{"string and float", "1.0", 1.0, false, false},
+		{"bool", true, true, true, true},
+		{"null", nil, nil, true, true},
+		{"null and string", nil, "", false, false},
+		{"timestamps", stamp, stamp.In(time.FixedZone("x", 3600)), true, true},
+	}
+
+	strict := &Engine{comparison: CompareStrict}
+	loose := &Engine{comparison: CompareLoose}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strict.equalScalars(tt.a, tt.b); got != tt.strict {
+				t.Errorf("strict equalScalars(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.strict)
+			}
+			if got := loose.equalScalars(tt.a, tt.b); got != tt.loose {
+				t.Errorf("loose equalScalars(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.loose)
+			}
+		})
+	}
+}
+
+func TestCompareNaN(t *testing.T) {
+	doc := "kind: Config\nmetadata:\n  name: app\nx: .nan\ny: 9007199254740993\n"
+	for _, mode := range []ValueComparison{CompareStrict, CompareLoose} {
+		if result := compareYAML(t, Options{Comparison: mode}, doc, doc); result.HasDifferences() {
+			t.Errorf("%s: a document compared with itself has differences: %v", mode, result.Modified)
+		}
+	}
+
+	changed := "kind: Config\nmetadata:\n  name: app\nx: .nan\ny: 9007199254740992\n"
+	result := compareYAML(t, Options{Comparison: CompareLoose}, doc, changed)
+	if changes := result.Modified["Config/app"].Changes; len(changes) != 1 || changes[0].Path.String() != "y" {
+		t.Errorf("changes = %v, want only y", changes)
+	}
+}
@@ -4,13 +4,61 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/fatih/color"
 	"github.com/tyuhara/yamldiff/internal/parser"
+	"gopkg.in/yaml.v3"
 )
 
 // Engine handles the comparison of YAML documents
 type Engine struct {
-	identifierPath string
+	identifier *parser.Identifier
+	listKeys   map[string]string
+	ignore     []*IgnoreRule
+	keepEmpty  bool
+	// renameThreshold is the similarity needed to pair a rename, or 0 when
+	// rename detection is off
+	renameThreshold float64
+	changeOrder     ChangeOrder
+	comparison      ValueComparison
+	formatting      bool
+	comments        bool
+	collapseAliases bool
+}
+
+// Options configures how an Engine compares documents
+type Options struct {
+	// ListKeys maps list paths to the field used to match their elements,
+	// e.g. "containers" → "name". They extend DefaultListKeys.
+	ListKeys map[string]string
+	// Ignore drops changes to matching fields. Documents whose only
+	// differences are ignored are not reported as modified.
+	Ignore []*IgnoreRule
+	// KeepEmpty compares empty documents (a bare "---") like any other
+	// document instead of skipping them
+	KeepEmpty bool
+	// DetectRenames pairs deleted and added documents whose content is at
+	// least RenameThreshold similar and reports them as renamed
+	DetectRenames bool
+	// RenameThreshold is the similarity from 0 to 1 needed for a rename;
+	// 0 means DefaultRenameThreshold
+	RenameThreshold float64
+	// ChangeOrder is the order of the changes of a document; empty means
+	// OrderDocument
+	ChangeOrder ChangeOrder
+	// Comparison selects how scalar values are compared; empty means
+	// CompareStrict
+	Comparison ValueComparison
+	// FormattingSensitive also compares how fields are written (quoting,
+	// indentation, comments). Documents that differ only in formatting are
+	// reported as reformatted.
+	FormattingSensitive bool
+	// DiffComments compares the head, line and foot comments of fields
+	// present in both documents. Documents whose only differences are
+	// comments are reported as reformatted. FormattingSensitive implies it.
+	DiffComments bool
+	// CollapseAliases reports a change to an anchored node once, at the
+	// anchor definition, instead of again at every alias and merge key (<<)
+	// that uses it
+	CollapseAliases bool
 }
 
 // Result represents the result of a comparison
@@ -18,31 +66,129 @@ type Result struct {
 	Added    map[string]parser.Document
 	Deleted  map[string]parser.Document
 	Modified map[string]ModifiedDoc
+	Moved    map[string]MovedDoc
+	Renamed  map[string]RenamedDoc
+	// Reformatted holds documents with the same content written
+	// differently, with FormattingSensitive, or whose comments changed,
+	// with DiffComments
+	Reformatted map[string]ModifiedDoc
+	Duplicates  []Duplicate
+}
+
+// MovedDoc represents a document that lives in a different file in the new
+// tree. A moved document may also be modified.
+type MovedDoc struct {
+	Old parser.Document
+	New parser.Document
+}
+
+// Side names one of the two inputs of a comparison
+type Side string
+
+const (
+	// SideOld is the first (old) input
+	SideOld Side = "old"
+	// SideNew is the second (new) input
+	SideNew Side = "new"
+)
+
+// Duplicate records two documents on the same side that resolve to the same
+// identifier. Only Second takes part in the comparison.
+type Duplicate struct {
+	Key    string
+	Side   Side
+	First  parser.Document
+	Second parser.Document
+}
+
+// String describes the duplicate using one-based document numbers, with the
+// item numbers of documents flattened from a List, and the positions of
+// both documents
+func (d Duplicate) String() string {
+	return fmt.Sprintf("duplicate identifier %q in %s input: documents %s (%s) and %s (%s)",
+		d.Key, d.Side, documentNumber(d.First), d.First.Position(), documentNumber(d.Second), d.Second.Position())
+}
+
+// documentNumber numbers a document as "2", or "2 item 3" for the third
+// item of a List in the second document
+func documentNumber(doc parser.Document) string {
+	if doc.Item > 0 {
+		return fmt.Sprintf("%d item %d", doc.Index+1, doc.Item)
+	}
+	return fmt.Sprintf("%d", doc.Index+1)
 }
 
 // ModifiedDoc represents a modified document with its changes
 type ModifiedDoc struct {
-	Old    parser.Document
-	New    parser.Document
-	Diffs  []string
+	Old     parser.Document
+	New     parser.Document
+	Changes []Change
+}
+
+// Diffs returns the changes rendered as one-line text diffs
+func (m ModifiedDoc) Diffs() []string {
+	diffs := make([]string, 0, len(m.Changes))
+	for _, change := range m.Changes {
+		diffs = append(diffs, change.String())
+	}
+	return diffs
 }
 
-// NewEngine creates a new diff engine with the specified identifier path
-func NewEngine(identifierPath string) *Engine {
+// NewEngine creates a new diff engine with the specified document identifier
+func NewEngine(identifier *parser.Identifier, opts Options) *Engine {
+	listKeys := make(map[string]string, len(DefaultListKeys)+len(opts.ListKeys))
+	for path, field := range DefaultListKeys {
+		listKeys[path] = field
+	}
+	for path, field := range opts.ListKeys {
+		listKeys[path] = field
+	}
+
+	var renameThreshold float64
+	if opts.DetectRenames {
+		renameThreshold = opts.RenameThreshold
+		if renameThreshold <= 0 {
+			renameThreshold = DefaultRenameThreshold
+		}
+	}
+
+	changeOrder := opts.ChangeOrder
+	if changeOrder == "" {
+		changeOrder = OrderDocument
+	}
+
+	comparison := opts.Comparison
+	if comparison == "" {
+		comparison = CompareStrict
+	}
+
 	return &Engine{
-		identifierPath: identifierPath,
+		identifier:      identifier,
+		listKeys:        listKeys,
+		ignore:          opts.Ignore,
+		keepEmpty:       opts.KeepEmpty,
+		renameThreshold: renameThreshold,
+		changeOrder:     changeOrder,
+		comparison:      comparison,
+		formatting:      opts.FormattingSensitive,
+		comments:        opts.DiffComments || opts.FormattingSensitive,
+		collapseAliases: opts.CollapseAliases,
 	}
 }
 
 // Compare compares two sets of documents
 func (e *Engine) Compare(docs1, docs2 []parser.Document) *Result {
-	map1 := e.makeDocMap(docs1)
-	map2 := e.makeDocMap(docs2)
+	map1, dups1 := e.makeDocMap(docs1, SideOld)
+	map2, dups2 := e.makeDocMap(docs2, SideNew)
 
 	result := &Result{
-		Added:    make(map[string]parser.Document),
-		Deleted:  make(map[string]parser.Document),
-		Modified: make(map[string]ModifiedDoc),
+		Added:       make(map[string]parser.Document),
+		Deleted:     make(map[string]parser.Document),
+		Modified:    make(map[string]ModifiedDoc),
+		Moved:       make(map[string]MovedDoc),
+		Renamed:     make(map[string]RenamedDoc),
+		Reformatted: make(map[string]ModifiedDoc),
+		Duplicates:  append(dups1, dups2...),
 	}
 
 	// Find all unique keys
@@ -62,136 +208,216 @@ func (e *Engine) Compare(docs1, docs2 []parser.Document) *Result {
 		if !exists1 && exists2 {
 			// Added
 			result.Added[key] = doc2
+			continue
 		} else if exists1 && !exists2 {
 			// Deleted
 			result.Deleted[key] = doc1
-		} else if doc1.Raw != doc2.Raw {
-			// Modified
-			diffs := parser.CompareValues("", doc1.Content, doc2.Content)
-			result.Modified[key] = ModifiedDoc{
-				Old:   doc1,
-				New:   doc2,
-				Diffs: diffs,
+			continue
+		}
+
+		if doc1.Source != "" && doc2.Source != "" && doc1.Source != doc2.Source {
+			// Moved to another file
+			result.Moved[key] = MovedDoc{Old: doc1, New: doc2}
+		}
+
+		// Modified when the content differs, not the text it was written
+		// as. Ignored fields and values equal under the comparison mode
+		// don't count.
+		changes, _ := e.filterIgnored(e.CompareValues(nil, doc1.Content, doc2.Content))
+		var formatting []Change
+		if e.formatting || e.comments {
+			formatting = append(documentComments(doc1, doc2), e.compareNodes(nil, doc1.Node, doc2.Node)...)
+			formatting, _ = e.filterIgnored(formatting)
+		}
+
+		switch {
+		case len(changes) > 0:
+			if e.collapseAliases {
+				changes = e.foldAliases(changes, doc1, doc2)
 			}
+			changes = append(changes, formatting...)
+			locateChanges(changes, doc1, doc2)
+			e.orderChanges(changes, doc1, doc2)
+			result.Modified[key] = ModifiedDoc{Old: e.withoutIgnored(doc1), New: e.withoutIgnored(doc2), Changes: changes}
+		case len(formatting) > 0:
+			locateChanges(formatting, doc1, doc2)
+			e.orderChanges(formatting, doc1, doc2)
+			result.Reformatted[key] = ModifiedDoc{Old: doc1, New: doc2, Changes: formatting}
 		}
 	}
 
+	if e.renameThreshold > 0 {
+		e.detectRenames(result)
+	}
+
 	return result
 }
 
-func (e *Engine) makeDocMap(docs []parser.Document) map[string]parser.Document {
-	result := make(map[string]parser.Document)
+// filterIgnored drops changes matched by an ignore rule and returns the
+// remaining changes with the number of dropped ones. Ignored fields inside
+// an added or deleted mapping or sequence are pruned from its value, and
+// the change is dropped when nothing else is left.
+func (e *Engine) filterIgnored(changes []Change) ([]Change, int) {
+	if len(e.ignore) == 0 {
+		return changes, 0
+	}
 
-	for i, doc := range docs {
-		key := parser.ExtractKey(doc.Content, e.identifierPath)
-		if key == "" {
-			// Fallback to index if no identifier found
-			key = fmt.Sprintf("__index_%d__", i)
+	kept := changes[:0]
+	for _, change := range changes {
+		if e.isIgnored(change.Path) {
+			continue
+		}
+		var pruned bool
+		switch change.Op {
+		case OpAdd:
+			change.NewValue, pruned = e.pruneIgnored(change.Path, change.NewValue)
+			pruned = pruned && isEmptyCollection(change.NewValue)
+		case OpDelete:
+			change.OldValue, pruned = e.pruneIgnored(change.Path, change.OldValue)
+			pruned = pruned && isEmptyCollection(change.OldValue)
+		}
+		if !pruned {
+			kept = append(kept, change)
 		}
-		doc.Key = key
-		result[key] = doc
 	}
-
-	return result
+	return kept, len(changes) - len(kept)
 }
 
-// HasDifferences returns true if there are any differences
-func (r *Result) HasDifferences() bool {
-	return len(r.Added) > 0 || len(r.Deleted) > 0 || len(r.Modified) > 0
+// withoutIgnored returns a document whose Raw text, shown by the line diff
+// views, leaves out the ignored fields of its content
+func (e *Engine) withoutIgnored(doc parser.Document) parser.Document {
+	if len(e.ignore) == 0 {
+		return doc
+	}
+	content, pruned := e.pruneIgnored(nil, doc.Content)
+	if !pruned {
+		return doc
+	}
+	if raw, err := yaml.Marshal(content); err == nil {
+		doc.Raw = string(raw)
+	}
+	return doc
 }
 
-// Print prints the diff result
-func (r *Result) Print(verbose bool) {
-	red := color.New(color.FgRed).SprintFunc()
-	green := color.New(color.FgGreen).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	cyan := color.New(color.FgCyan).SprintFunc()
-
-	if !verbose {
-		// Non-verbose: show key names only
-		// Print added documents
-		keys := sortedKeys(r.Added)
-		for _, key := range keys {
-			fmt.Printf("%s %s\n", green("+ Added:"), cyan(key))
+// pruneIgnored returns a copy of a value at path without its ignored
+// fields, and whether any were found. Mappings and sequences left empty by
+// pruning are removed too. The value itself is not modified.
+func (e *Engine) pruneIgnored(path Path, v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		pruned := false
+		for key, item := range val {
+			childPath := path.Child(key)
+			if e.isIgnored(childPath) {
+				pruned = true
+				continue
+			}
+			item, itemPruned := e.pruneIgnored(childPath, item)
+			pruned = pruned || itemPruned
+			if !itemPruned || !isEmptyCollection(item) {
+				result[key] = item
+			}
 		}
-
-		// Print deleted documents
-		keys = sortedKeys(r.Deleted)
-		for _, key := range keys {
-			fmt.Printf("%s %s\n", red("- Deleted:"), cyan(key))
+		if !pruned {
+			return val, false
 		}
-
-		// Print modified documents
-		keys = sortedKeysModified(r.Modified)
-		for _, key := range keys {
-			mod := r.Modified[key]
-			fmt.Printf("%s %s\n", yellow("~ Modified:"), cyan(key))
-			for _, diff := range mod.Diffs {
-				fmt.Printf("  %s\n", diff)
+		return result, true
+	case []interface{}:
+		var keys []string
+		field := e.listKey(path)
+		if field != "" {
+			keys, _ = keyedElements(val, field)
+		}
+		result := make([]interface{}, 0, len(val))
+		pruned := false
+		for i, item := range val {
+			elemPath := path.Element(i)
+			if len(keys) == len(val) {
+				elemPath = path.Match(field, keys[i])
 			}
-			fmt.Println()
-		}
-
-		// Print summary
-		r.PrintSummary()
-	} else {
-		// Verbose: show summary first, then full document content with diff-style prefixes
-		r.PrintSummaryCompact()
-
-		// Print added documents with "+" prefix
-		keys := sortedKeys(r.Added)
-		for _, key := range keys {
-			doc := r.Added[key]
-			lines := parser.SplitLines(doc.Raw)
-			for _, line := range lines {
-				if len(line) > 0 {
-					fmt.Printf("%s\n", green("+ "+line))
-				}
+			if e.isIgnored(elemPath) {
+				pruned = true
+				continue
 			}
-		}
-
-		// Print deleted documents with "-" prefix
-		keys = sortedKeys(r.Deleted)
-		for _, key := range keys {
-			doc := r.Deleted[key]
-			lines := parser.SplitLines(doc.Raw)
-			for _, line := range lines {
-				if len(line) > 0 {
-					fmt.Printf("%s\n", red("- "+line))
-				}
+			item, itemPruned := e.pruneIgnored(elemPath, item)
+			pruned = pruned || itemPruned
+			if !itemPruned || !isEmptyCollection(item) {
+				result = append(result, item)
 			}
 		}
+		if !pruned {
+			return val, false
+		}
+		return result, true
+	default:
+		return v, false
+	}
+}
 
-		// Print modified documents
-		keys = sortedKeysModified(r.Modified)
-		for _, key := range keys {
-			mod := r.Modified[key]
-			fmt.Printf("%s %s\n", yellow("~ Modified:"), cyan(key))
-			for _, diff := range mod.Diffs {
-				fmt.Printf("  %s\n", diff)
-			}
-			fmt.Println()
+func isEmptyCollection(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return len(val) == 0
+	case []interface{}:
+		return len(val) == 0
+	default:
+		return false
+	}
+}
+
+func (e *Engine) isIgnored(path Path) bool {
+	for _, rule := range e.ignore {
+		if rule.Match(path) {
+			return true
 		}
 	}
+	return false
 }
 
-// PrintSummary prints a summary of changes
-func (r *Result) PrintSummary() {
-	green := color.New(color.FgGreen).SprintFunc()
-	red := color.New(color.FgRed).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	bold := color.New(color.Bold).SprintFunc()
+func (e *Engine) makeDocMap(docs []parser.Document, side Side) (map[string]parser.Document, []Duplicate) {
+	result := make(map[string]parser.Document)
+	var duplicates []Duplicate
 
-	fmt.Printf("\n%s\n", bold("Summary:"))
-	fmt.Printf("  %s: %d\n", green("Added"), len(r.Added))
-	fmt.Printf("  %s: %d\n", red("Deleted"), len(r.Deleted))
-	fmt.Printf("  %s: %d\n", yellow("Modified"), len(r.Modified))
+	index := 0
+	for _, doc := range docs {
+		if doc.IsEmpty() && !e.keepEmpty {
+			continue
+		}
+		key := e.identifier.Extract(doc.Content)
+		if key == "" {
+			// Fallback to index if no identifier found. Skipped empty
+			// documents don't shift the index.
+			key = fmt.Sprintf("__index_%d__", index)
+		}
+		index++
+		doc.Key = key
+		if prev, exists := result[key]; exists {
+			duplicates = append(duplicates, Duplicate{
+				Key:    key,
+				Side:   side,
+				First:  prev,
+				Second: doc,
+			})
+		}
+		result[key] = doc
+	}
+
+	return result, duplicates
+}
+
+// HasDifferences returns true if there are any differences in content, or
+// in formatting when it was compared. Documents that only moved to another
+// file don't count.
+func (r *Result) HasDifferences() bool {
+	return r.HasContentChanges() || len(r.Reformatted) > 0
 }
 
-// PrintSummaryCompact prints a compact summary suitable for verbose output
-func (r *Result) PrintSummaryCompact() {
-	fmt.Printf("Summary\n")
-	fmt.Printf("%d added, %d deleted, %d modified\n", len(r.Added), len(r.Deleted), len(r.Modified))
+// HasContentChanges returns true if any document was added, deleted,
+// modified or renamed. Documents that only moved or were reformatted don't
+// count.
+func (r *Result) HasContentChanges() bool {
+	return len(r.Added) > 0 || len(r.Deleted) > 0 || len(r.Modified) > 0 || len(r.Renamed) > 0
 }
 
 func sortedKeys(m map[string]parser.Document) []string {
@@ -203,6 +429,24 @@ func sortedKeys(m map[string]parser.Document) []string {
 	return keys
 }
 
+func sortedKeysMoved(m map[string]MovedDoc) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeysRenamed(m map[string]RenamedDoc) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func sortedKeysModified(m map[string]ModifiedDoc) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -0,0 +1,291 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// compareYAML compares two YAML inputs with the Kubernetes identifier
+func compareYAML(t *testing.T, opts Options, oldYAML, newYAML string) *Result {
+	t.Helper()
+	return compareDocs(t, opts, parseYAML(t, "old.yaml", oldYAML), parseYAML(t, "new.yaml", newYAML))
+}
+
+func compareDocs(t *testing.T, opts Options, oldDocs, newDocs []parser.Document) *Result {
+	t.Helper()
+	identifier, err := parser.ParseIdentifier(parser.KubernetesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEngine(identifier, opts).Compare(oldDocs, newDocs)
+}
+
+func parseYAML(t *testing.T, filename, src string) []parser.Document {
+	t.Helper()
+	docs, err := parser.ParseBytes([]byte(src), filename, parser.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return docs
+}
+
+func TestFilterIgnoredPrunesValues(t *testing.T) {
+	oldYAML := `kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+	tests := []struct {
+		name    string
+		ignore  string
+		newYAML string
+		want    []string
+	}{
+		{
+			name:   "only ignored keys added",
+			ignore: `metadata.annotations["checksum/*"]`,
+			newYAML: `kind: Deployment
+metadata:
+  name: web
+  annotations:
+    checksum/config: abc
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`,
+		},
+		{
+			name:   "ignored keys pruned from the added value",
+			ignore: `metadata.annotations["checksum/*"]`,
+			newYAML: `kind: Deployment
+metadata:
+  name: web
+  annotations:
+    checksum/config: abc
+    owner: team-a
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`,
+			want: []string{"+ metadata.annotations: map[owner:team-a]"},
+		},
+		{
+			name:   "nested mappings left empty",
+			ignore: `**.checksum`,
+			newYAML: `kind: Deployment
+metadata:
+  name: web
+  extra:
+    hashes:
+      checksum: abc
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`,
+		},
+		{
+			name:   "keyed list elements",
+			ignore: `spec.template.spec.containers[name=sidecar]`,
+			newYAML: `kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+      - name: sidecar
+        image: envoy
+`,
+		},
+		{
+			name:   "empty values are kept",
+			ignore: `metadata.annotations["checksum/*"]`,
+			newYAML: `kind: Deployment
+metadata:
+  name: web
+  annotations: {}
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`,
+			want: []string{"+ metadata.annotations: map[]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseIgnoreRules([]string{tt.ignore})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pair := range [][2]string{{oldYAML, tt.newYAML}, {tt.newYAML, oldYAML}} {
+				result := compareYAML(t, Options{Ignore: rules}, pair[0], pair[1])
+				var got []string
+				for _, doc := range result.Modified {
+					for _, change := range doc.Changes {
+						got = append(got, change.String()[2:])
+					}
+				}
+				var want []string
+				for _, w := range tt.want {
+					want = append(want, w[2:])
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("changes = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHasContentChanges(t *testing.T) {
+	oldYAML := "kind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value # old\n"
+	tests := []struct {
+		name        string
+		newYAML     string
+		differences bool
+		content     bool
+	}{
+		{"same", oldYAML, false, false},
+		{"comment only", "kind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value # new\n", true, false},
+		{"content", "kind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: other # new\n", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := compareYAML(t, Options{DiffComments: true}, oldYAML, tt.newYAML)
+			if got := result.HasDifferences(); got != tt.differences {
+				t.Errorf("HasDifferences() = %v, want %v", got, tt.differences)
+			}
+			if got := result.HasContentChanges(); got != tt.content {
+				t.Errorf("HasContentChanges() = %v, want %v", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestDocumentComments(t *testing.T) {
+	body := "kind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value\n"
+	tests := []struct {
+		name             string
+		oldYAML, newYAML string
+		want             []string
+	}{
+		{"head", "# owner: team-a\n\n" + body, "# owner: team-b\n\n" + body, []string{`. head "# owner: team-a" "# owner: team-b"`}},
+		{"foot", body, body + "\n# end\n", []string{`. foot "" "# end"`}},
+		{"first field", "# owner: team-a\n" + body, "# owner: team-b\n" + body, []string{`kind head "# owner: team-a" "# owner: team-b"`}},
+		{"same", "# owner: team-a\n\n" + body, "# owner: team-a\n\n" + body, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := compareYAML(t, Options{DiffComments: true}, tt.oldYAML, tt.newYAML)
+			if len(result.Modified) != 0 {
+				t.Errorf("Modified = %v, want none", result.Modified)
+			}
+			var got []string
+			for _, change := range result.Reformatted["ConfigMap/app"].Changes {
+				got = append(got, fmt.Sprintf("%s %s %q %q", change.Path, change.Comment, change.OldValue, change.NewValue))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateInList(t *testing.T) {
+	docs, err := parser.FlattenLists(parseYAML(t, "live.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: other
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := compareDocs(t, Options{}, docs, docs)
+	if len(result.Duplicates) != 2 {
+		t.Fatalf("got %d duplicates, want one per side", len(result.Duplicates))
+	}
+	want := `duplicate identifier "ConfigMap/app" in old input: documents 1 (live.yaml:1:1) and 2 item 2 (live.yaml:13:3)`
+	if got := result.Duplicates[0].String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, `"first_item"`) || !strings.Contains(out, `"second_item": 2`) {
+		t.Errorf("JSON duplicates do not number the List item:\n%s", out)
+	}
+}
+
+func TestMoved(t *testing.T) {
+	app := "kind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value\n"
+	tests := []struct {
+		name                 string
+		oldSource, newSource string
+		newYAML              string
+		moved, modified      bool
+	}{
+		{"same file", "app.yaml", "app.yaml", app, false, false},
+		{"moved", "all.yaml", "app/configmap.yaml", app, true, false},
+		{"moved and modified", "all.yaml", "app/configmap.yaml", strings.Replace(app, "value", "other", 1), true, true},
+		{"single files", "", "", app, false, false},
+		{"single file against a directory", "", "app.yaml", app, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDocs, newDocs := parseYAML(t, "old.yaml", app), parseYAML(t, "new.yaml", tt.newYAML)
+			oldDocs[0].Source, newDocs[0].Source = tt.oldSource, tt.newSource
+
+			result := compareDocs(t, Options{}, oldDocs, newDocs)
+			moved, ok := result.Moved["ConfigMap/app"]
+			if ok != tt.moved {
+				t.Errorf("Moved = %v, want moved %v", result.Moved, tt.moved)
+			}
+			if ok && (moved.Old.Source != tt.oldSource || moved.New.Source != tt.newSource) {
+				t.Errorf("moved from %q to %q, want %q to %q", moved.Old.Source, moved.New.Source, tt.oldSource, tt.newSource)
+			}
+			if _, ok := result.Modified["ConfigMap/app"]; ok != tt.modified {
+				t.Errorf("Modified = %v, want modified %v", result.Modified, tt.modified)
+			}
+		})
+	}
+}
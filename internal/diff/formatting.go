@@ -0,0 +1,183 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// FormattingKind tells what kind of formatting an OpFormat change is about
+type FormattingKind string
+
+const (
+	// FormattingStyle is a change of scalar quoting (plain, double-quoted,
+	// single-quoted, literal, folded) or collection style (block, flow)
+	FormattingStyle FormattingKind = "style"
+	// FormattingWhitespace is a change of the indentation of a block
+	// collection
+	FormattingWhitespace FormattingKind = "whitespace"
+)
+
+// CommentPosition tells where a comment sits relative to its node
+type CommentPosition string
+
+const (
+	// CommentHead is on the lines above the node
+	CommentHead CommentPosition = "head"
+	// CommentLine is at the end of the node's line
+	CommentLine CommentPosition = "line"
+	// CommentFoot is on the lines below the node
+	CommentFoot CommentPosition = "foot"
+)
+
+// compareNodes walks the old and new nodes of a document side by side and
+// returns OpComment changes for fields present in both whose comments
+// differ, and with FormattingSensitive OpFormat changes for their style and
+// indentation. Content changes are left to CompareValues.
+func (e *Engine) compareNodes(path Path, oldNode, newNode *yaml.Node) []Change {
+	if oldNode == nil || newNode == nil || oldNode.Kind != newNode.Kind || oldNode.Kind == yaml.AliasNode {
+		return nil
+	}
+
+	changes := commentChanges(path, oldNode, newNode)
+	if oldStyle, newStyle := styleName(oldNode), styleName(newNode); e.formatting && oldStyle != newStyle {
+		changes = append(changes, formatChange(path, FormattingStyle, oldStyle, newStyle))
+	}
+
+	switch oldNode.Kind {
+	case yaml.MappingNode:
+		newEntries := make(map[string][2]*yaml.Node, len(newNode.Content)/2)
+		for i := 0; i+1 < len(newNode.Content); i += 2 {
+			newEntries[newNode.Content[i].Value] = [2]*yaml.Node{newNode.Content[i], newNode.Content[i+1]}
+		}
+		for i := 0; i+1 < len(oldNode.Content); i += 2 {
+			oldKey, oldValue := oldNode.Content[i], oldNode.Content[i+1]
+			entry, ok := newEntries[oldKey.Value]
+			if !ok {
+				continue
+			}
+			newKey, newValue := entry[0], entry[1]
+			entryPath := path.Child(oldKey.Value)
+
+			changes = append(changes, commentChanges(entryPath, oldKey, newKey)...)
+			if e.formatting {
+				changes = append(changes, indentChange(entryPath, oldValue.Column-oldKey.Column, newValue.Column-newKey.Column, oldValue, newValue)...)
+			}
+			changes = append(changes, e.compareNodes(entryPath, oldValue, newValue)...)
+		}
+	case yaml.SequenceNode:
+		for _, pair := range e.pairElements(path, oldNode, newNode) {
+			oldElem, newElem, elemPath := pair.old, pair.new, pair.path
+			if e.formatting {
+				changes = append(changes, indentChange(elemPath, oldElem.Column-oldNode.Column, newElem.Column-newNode.Column, oldElem, newElem)...)
+			}
+			changes = append(changes, e.compareNodes(elemPath, oldElem, newElem)...)
+		}
+	}
+	return changes
+}
+
+type elementPair struct {
+	old, new *yaml.Node
+	path     Path
+}
+
+// pairElements pairs the elements of two sequences the way compareSequences
+// does: by merge key when every old element has one, otherwise by index
+func (e *Engine) pairElements(path Path, oldNode, newNode *yaml.Node) []elementPair {
+	var pairs []elementPair
+	if field := e.listKey(path); field != "" {
+		keys := elementKeys(oldNode, field)
+		if len(keys) == len(oldNode.Content) {
+			for i, key := range keys {
+				if elem := matchElement(newNode, field, key); elem != nil {
+					pairs = append(pairs, elementPair{oldNode.Content[i], elem, path.Match(field, key)})
+				}
+			}
+			return pairs
+		}
+	}
+
+	for i := 0; i < len(oldNode.Content) && i < len(newNode.Content); i++ {
+		pairs = append(pairs, elementPair{oldNode.Content[i], newNode.Content[i], path.Element(i)})
+	}
+	return pairs
+}
+
+// commentChanges compares the head, line and foot comments of two nodes
+func commentChanges(path Path, oldNode, newNode *yaml.Node) []Change {
+	var changes []Change
+	for _, c := range []struct {
+		position CommentPosition
+		old, new string
+	}{
+		{CommentHead, oldNode.HeadComment, newNode.HeadComment},
+		{CommentLine, oldNode.LineComment, newNode.LineComment},
+		{CommentFoot, oldNode.FootComment, newNode.FootComment},
+	} {
+		if c.old != c.new {
+			changes = append(changes, Change{
+				Op:       OpComment,
+				Path:     path,
+				Comment:  c.position,
+				OldValue: c.old,
+				NewValue: c.new,
+			})
+		}
+	}
+	return changes
+}
+
+// documentComments compares the comments of two documents that are not
+// attached to any node, reporting them at the root path
+func documentComments(oldDoc, newDoc parser.Document) []Change {
+	return commentChanges(nil,
+		&yaml.Node{HeadComment: oldDoc.HeadComment, FootComment: oldDoc.FootComment},
+		&yaml.Node{HeadComment: newDoc.HeadComment, FootComment: newDoc.FootComment})
+}
+
+// indentChange reports a change of indentation of a block collection.
+// Scalars and flow collections are not indented.
+func indentChange(path Path, oldIndent, newIndent int, oldNode, newNode *yaml.Node) []Change {
+	if !isBlockCollection(oldNode) || !isBlockCollection(newNode) || oldIndent == newIndent {
+		return nil
+	}
+	return []Change{formatChange(path, FormattingWhitespace,
+		fmt.Sprintf("indent %d", oldIndent), fmt.Sprintf("indent %d", newIndent))}
+}
+
+func isBlockCollection(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && node.Style&yaml.FlowStyle == 0
+}
+
+// styleName names the presentation style of a node
+func styleName(node *yaml.Node) string {
+	switch {
+	case node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode:
+		if node.Style&yaml.FlowStyle != 0 {
+			return "flow"
+		}
+		return "block"
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		return "double-quoted"
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		return "single-quoted"
+	case node.Style&yaml.LiteralStyle != 0:
+		return "literal"
+	case node.Style&yaml.FoldedStyle != 0:
+		return "folded"
+	default:
+		return "plain"
+	}
+}
+
+func formatChange(path Path, kind FormattingKind, oldValue, newValue string) Change {
+	return Change{
+		Op:         OpFormat,
+		Path:       path,
+		Formatting: kind,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+	}
+}
@@ -0,0 +1,245 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IgnoreRule matches field paths whose changes should not be reported.
+//
+// A pattern is a dot path whose segments may contain wildcards:
+//   - `*` and `?` match any run of characters / a single character in a key
+//   - `**` matches any number of segments, including none
+//   - `["key"]` quotes a key containing dots or other special characters
+//   - `[*]` matches any list element, `[2]` an index and `[name=app]` a
+//     keyed element (the value may contain wildcards)
+//
+// A rule also ignores everything below the fields it matches, so `status`
+// and `status.**` are equivalent.
+type IgnoreRule struct {
+	pattern  string
+	segments []ignoreSegment
+}
+
+type ignoreSegmentKind int
+
+const (
+	ignoreKey ignoreSegmentKind = iota
+	ignoreAnyDepth
+	ignoreAnyElement
+	ignoreIndex
+	ignoreMatch
+)
+
+type ignoreSegment struct {
+	kind  ignoreSegmentKind
+	glob  string
+	index int
+	field string
+}
+
+// ParseIgnoreRules parses a list of ignore patterns
+func ParseIgnoreRules(patterns []string) ([]*IgnoreRule, error) {
+	rules := make([]*IgnoreRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, err := ParseIgnoreRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseIgnoreRule parses a single ignore pattern
+func ParseIgnoreRule(pattern string) (*IgnoreRule, error) {
+	rule := &IgnoreRule{pattern: pattern}
+
+	rest := strings.TrimSpace(pattern)
+	if rest == "" {
+		return nil, fmt.Errorf("ignore pattern is empty")
+	}
+
+	for rest != "" {
+		if rest[0] == '.' {
+			rest = rest[1:]
+			continue
+		}
+
+		if rest[0] == '[' {
+			end := closingBracket(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid ignore pattern %q: unterminated [", pattern)
+			}
+			seg, err := parseBracketSegment(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+			}
+			rule.segments = append(rule.segments, seg)
+			rest = rest[end+1:]
+			continue
+		}
+
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		rest = rest[end:]
+
+		if key == "**" {
+			rule.segments = append(rule.segments, ignoreSegment{kind: ignoreAnyDepth})
+		} else {
+			rule.segments = append(rule.segments, ignoreSegment{kind: ignoreKey, glob: key})
+		}
+	}
+
+	return rule, nil
+}
+
+// String returns the pattern the rule was parsed from
+func (r *IgnoreRule) String() string {
+	return r.pattern
+}
+
+// Match reports whether the path or one of its parents is matched by the
+// rule
+func (r *IgnoreRule) Match(path Path) bool {
+	return matchIgnoreSegments(r.segments, path)
+}
+
+func matchIgnoreSegments(segments []ignoreSegment, path Path) bool {
+	if len(segments) == 0 {
+		// Everything below a matched field is ignored too
+		return true
+	}
+
+	if segments[0].kind == ignoreAnyDepth {
+		for i := 0; i <= len(path); i++ {
+			if matchIgnoreSegments(segments[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 || !segments[0].match(path[0]) {
+		return false
+	}
+	return matchIgnoreSegments(segments[1:], path[1:])
+}
+
+func (s ignoreSegment) match(seg PathSegment) bool {
+	switch s.kind {
+	case ignoreKey:
+		return seg.Kind == KeySegment && globMatch(s.glob, seg.Key)
+	case ignoreAnyElement:
+		return seg.Kind == IndexSegment || seg.Kind == MatchSegment
+	case ignoreIndex:
+		return seg.Kind == IndexSegment && seg.Index == s.index
+	case ignoreMatch:
+		return seg.Kind == MatchSegment && seg.MatchField == s.field && globMatch(s.glob, seg.MatchValue)
+	default:
+		return false
+	}
+}
+
+// parseBracketSegment parses the inside of a [...] segment
+func parseBracketSegment(inner string) (ignoreSegment, error) {
+	inner = strings.TrimSpace(inner)
+
+	switch {
+	case inner == "*":
+		return ignoreSegment{kind: ignoreAnyElement}, nil
+	case isQuoted(inner):
+		key, err := strconv.Unquote(normalizeQuotes(inner))
+		if err != nil {
+			return ignoreSegment{}, fmt.Errorf("bad quoted key %s", inner)
+		}
+		return ignoreSegment{kind: ignoreKey, glob: key}, nil
+	case strings.Contains(inner, "="):
+		parts := strings.SplitN(inner, "=", 2)
+		field, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if isQuoted(value) {
+			unquoted, err := strconv.Unquote(normalizeQuotes(value))
+			if err != nil {
+				return ignoreSegment{}, fmt.Errorf("bad quoted value %s", value)
+			}
+			value = unquoted
+		}
+		return ignoreSegment{kind: ignoreMatch, field: field, glob: value}, nil
+	default:
+		index, err := strconv.Atoi(inner)
+		if err != nil || index < 0 {
+			return ignoreSegment{}, fmt.Errorf("bad list selector [%s]", inner)
+		}
+		return ignoreSegment{kind: ignoreIndex, index: index}, nil
+	}
+}
+
+// closingBracket returns the index of the ] closing the [ at s[0], skipping
+// over quoted strings, or -1
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		case quote == 0 && s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0]
+}
+
+// normalizeQuotes turns a single-quoted string into a double-quoted one so
+// strconv.Unquote accepts multi-character keys
+func normalizeQuotes(s string) string {
+	if s[0] == '\'' {
+		return strconv.Quote(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// globMatch matches s against a pattern where * matches any run of
+// characters (including / and .) and ? matches a single character
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+			s = s[1:]
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return s == ""
+}
@@ -0,0 +1,289 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// JSONSchemaVersion is the version of the JSON output schema. It is bumped
+// whenever a field is removed or changes meaning; new fields may be added
+// without a bump.
+const JSONSchemaVersion = 1
+
+type jsonResult struct {
+	SchemaVersion int               `json:"schema_version"`
+	Summary       jsonSummary       `json:"summary"`
+	Added         []jsonDocument    `json:"added"`
+	Deleted       []jsonDocument    `json:"deleted"`
+	Modified      []jsonModifiedDoc `json:"modified"`
+	Moved         []jsonMovedDoc    `json:"moved"`
+	Renamed       []jsonRenamedDoc  `json:"renamed"`
+	Reformatted   []jsonModifiedDoc `json:"reformatted"`
+	Duplicates    []jsonDuplicate   `json:"duplicates"`
+}
+
+type jsonMovedDoc struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type jsonRenamedDoc struct {
+	OldKey      string        `json:"old_key"`
+	NewKey      string        `json:"new_key"`
+	Similarity  float64       `json:"similarity"`
+	OldPosition *jsonPosition `json:"old_position,omitempty"`
+	NewPosition *jsonPosition `json:"new_position,omitempty"`
+	Changes     []jsonChange  `json:"changes"`
+}
+
+type jsonDuplicate struct {
+	Key            string        `json:"key"`
+	Side           Side          `json:"side"`
+	First          int           `json:"first_document"`
+	Second         int           `json:"second_document"`
+	FirstItem      int           `json:"first_item,omitempty"`
+	SecondItem     int           `json:"second_item,omitempty"`
+	FirstPosition  *jsonPosition `json:"first_position,omitempty"`
+	SecondPosition *jsonPosition `json:"second_position,omitempty"`
+}
+
+type jsonPosition struct {
+	File     string `json:"file"`
+	Document int    `json:"document"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+type jsonSummary struct {
+	Added       int `json:"added"`
+	Deleted     int `json:"deleted"`
+	Modified    int `json:"modified"`
+	Moved       int `json:"moved"`
+	Renamed     int `json:"renamed"`
+	Reformatted int `json:"reformatted"`
+}
+
+type jsonDocument struct {
+	Key      string        `json:"key"`
+	Position *jsonPosition `json:"position,omitempty"`
+	Content  interface{}   `json:"content"`
+}
+
+type jsonModifiedDoc struct {
+	Key         string        `json:"key"`
+	OldPosition *jsonPosition `json:"old_position,omitempty"`
+	NewPosition *jsonPosition `json:"new_position,omitempty"`
+	Changes     []jsonChange  `json:"changes"`
+}
+
+type jsonChange struct {
+	Op           Operation       `json:"op"`
+	Path         string          `json:"path"`
+	PathSegments []jsonSegment   `json:"path_segments"`
+	OldValue     *interface{}    `json:"old_value,omitempty"`
+	NewValue     *interface{}    `json:"new_value,omitempty"`
+	Formatting   string          `json:"formatting,omitempty"`
+	Comment      string          `json:"comment,omitempty"`
+	AliasSites   []jsonAliasSite `json:"alias_sites,omitempty"`
+	OldType      string          `json:"old_type,omitempty"`
+	NewType      string          `json:"new_type,omitempty"`
+	OldPosition  *jsonPosition   `json:"old_position,omitempty"`
+	NewPosition  *jsonPosition   `json:"new_position,omitempty"`
+}
+
+type jsonAliasSite struct {
+	Path   string `json:"path"`
+	Anchor string `json:"anchor"`
+}
+
+type jsonSegment struct {
+	Type  string `json:"type"`
+	Key   string `json:"key,omitempty"`
+	Index *int   `json:"index,omitempty"`
+	Field string `json:"field,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// WriteJSON writes the result as a versioned JSON document
+func (r *Result) WriteJSON(w io.Writer) error {
+	out := jsonResult{
+		SchemaVersion: JSONSchemaVersion,
+		Summary: jsonSummary{
+			Added:       len(r.Added),
+			Deleted:     len(r.Deleted),
+			Modified:    len(r.Modified),
+			Moved:       len(r.Moved),
+			Renamed:     len(r.Renamed),
+			Reformatted: len(r.Reformatted),
+		},
+		Added:       []jsonDocument{},
+		Deleted:     []jsonDocument{},
+		Modified:    []jsonModifiedDoc{},
+		Moved:       []jsonMovedDoc{},
+		Renamed:     []jsonRenamedDoc{},
+		Reformatted: []jsonModifiedDoc{},
+		Duplicates:  []jsonDuplicate{},
+	}
+
+	for _, key := range sortedKeys(r.Added) {
+		out.Added = append(out.Added, newJSONDocument(key, r.Added[key]))
+	}
+	for _, key := range sortedKeys(r.Deleted) {
+		out.Deleted = append(out.Deleted, newJSONDocument(key, r.Deleted[key]))
+	}
+	for _, key := range sortedKeysModified(r.Modified) {
+		out.Modified = append(out.Modified, newJSONModifiedDoc(key, r.Modified[key]))
+	}
+	for _, key := range sortedKeysMoved(r.Moved) {
+		moved := r.Moved[key]
+		out.Moved = append(out.Moved, jsonMovedDoc{Key: key, From: moved.Old.Source, To: moved.New.Source})
+	}
+	for _, key := range sortedKeysRenamed(r.Renamed) {
+		renamed := r.Renamed[key]
+		ren := jsonRenamedDoc{
+			OldKey:      renamed.OldKey,
+			NewKey:      renamed.NewKey,
+			Similarity:  math.Round(renamed.Similarity*1000) / 1000,
+			OldPosition: newJSONPosition(renamed.Old.Position()),
+			NewPosition: newJSONPosition(renamed.New.Position()),
+			Changes:     []jsonChange{},
+		}
+		for _, change := range renamed.Changes {
+			ren.Changes = append(ren.Changes, newJSONChange(change))
+		}
+		out.Renamed = append(out.Renamed, ren)
+	}
+	for _, key := range sortedKeysModified(r.Reformatted) {
+		out.Reformatted = append(out.Reformatted, newJSONModifiedDoc(key, r.Reformatted[key]))
+	}
+	for _, dup := range r.Duplicates {
+		out.Duplicates = append(out.Duplicates, jsonDuplicate{
+			Key:            dup.Key,
+			Side:           dup.Side,
+			First:          dup.First.Index,
+			Second:         dup.Second.Index,
+			FirstItem:      dup.First.Item,
+			SecondItem:     dup.Second.Item,
+			FirstPosition:  newJSONPosition(dup.First.Position()),
+			SecondPosition: newJSONPosition(dup.Second.Position()),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(out)
+}
+
+func newJSONDocument(key string, doc parser.Document) jsonDocument {
+	return jsonDocument{
+		Key:      key,
+		Position: newJSONPosition(doc.Position()),
+		Content:  jsonValue(doc.Content),
+	}
+}
+
+func newJSONModifiedDoc(key string, modified ModifiedDoc) jsonModifiedDoc {
+	mod := jsonModifiedDoc{
+		Key:         key,
+		OldPosition: newJSONPosition(modified.Old.Position()),
+		NewPosition: newJSONPosition(modified.New.Position()),
+		Changes:     []jsonChange{},
+	}
+	for _, change := range modified.Changes {
+		mod.Changes = append(mod.Changes, newJSONChange(change))
+	}
+	return mod
+}
+
+func newJSONPosition(pos parser.Position) *jsonPosition {
+	if pos.IsZero() {
+		return nil
+	}
+	return &jsonPosition{
+		File:     pos.File,
+		Document: pos.Document,
+		Line:     pos.Line,
+		Column:   pos.Column,
+	}
+}
+
+func newJSONChange(c Change) jsonChange {
+	out := jsonChange{
+		Op:           c.Op,
+		Path:         c.Path.String(),
+		PathSegments: []jsonSegment{},
+		Formatting:   string(c.Formatting),
+		Comment:      string(c.Comment),
+		OldType:      c.OldType,
+		NewType:      c.NewType,
+		OldPosition:  newJSONPosition(c.OldPosition),
+		NewPosition:  newJSONPosition(c.NewPosition),
+	}
+	// Pointers keep an explicit null apart from a value that is absent
+	// because of the operation
+	if c.Op != OpAdd {
+		oldValue := jsonValue(c.OldValue)
+		out.OldValue = &oldValue
+	}
+	if c.Op != OpDelete {
+		newValue := jsonValue(c.NewValue)
+		out.NewValue = &newValue
+	}
+
+	for _, site := range c.AliasSites {
+		out.AliasSites = append(out.AliasSites, jsonAliasSite{Path: site.Path.String(), Anchor: site.Anchor})
+	}
+
+	for _, seg := range c.Path {
+		switch seg.Kind {
+		case IndexSegment:
+			index := seg.Index
+			out.PathSegments = append(out.PathSegments, jsonSegment{Type: "index", Index: &index})
+		case MatchSegment:
+			out.PathSegments = append(out.PathSegments, jsonSegment{Type: "match", Field: seg.MatchField, Value: seg.MatchValue})
+		default:
+			out.PathSegments = append(out.PathSegments, jsonSegment{Type: "key", Key: seg.Key})
+		}
+	}
+
+	return out
+}
+
+// jsonValue converts a decoded YAML value into one encoding/json can
+// marshal: mappings with non-string keys get stringified keys and
+// non-finite floats become strings
+func jsonValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = jsonValue(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[fmt.Sprintf("%v", k)] = jsonValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = jsonValue(item)
+		}
+		return out
+	case float64:
+		if math.IsInf(val, 0) || math.IsNaN(val) {
+			return fmt.Sprintf("%v", val)
+		}
+		return val
+	default:
+		return val
+	}
+}
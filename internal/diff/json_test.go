@@ -0,0 +1,282 @@
+package diff
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestWriteJSONGolden(t *testing.T) {
+	tests := []struct {
+		name                 string
+		opts                 Options
+		oldYAML, newYAML     string
+		oldSource, newSource string
+	}{
+		{
+			name: "added_deleted_modified",
+			oldYAML: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old
+data:
+  key: value
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+      - name: sidecar
+        image: envoy:1.28
+`,
+			newYAML: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.26
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+data:
+  key: value
+`,
+		},
+		{
+			name: "null_and_non_finite",
+			oldYAML: `kind: Config
+metadata:
+  name: app
+spec:
+  replicas: 1
+  removed: null
+  ratio: .inf
+  ports: [80]
+`,
+			newYAML: `kind: Config
+metadata:
+  name: app
+spec:
+  replicas: null
+  added: null
+  ratio: .nan
+  limit: -.inf
+  ports: [80, "443"]
+`,
+		},
+		{
+			name: "moved",
+			oldYAML: `kind: ConfigMap
+metadata:
+  name: app
+data:
+  key: value
+`,
+			newYAML: `kind: ConfigMap
+metadata:
+  name: app
+data:
+  key: value
+`,
+			oldSource: "all.yaml",
+			newSource: "app/configmap.yaml",
+		},
+		{
+			name: "renamed",
+			opts: Options{DetectRenames: true},
+			oldYAML: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  selector:
+    app: web
+`,
+			newYAML: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-v2
+spec:
+  replicas: 3
+  selector:
+    app: web
+`,
+		},
+		{
+			name: "reformatted",
+			opts: Options{FormattingSensitive: true},
+			oldYAML: `kind: ConfigMap
+metadata:
+  name: app
+  labels: {app: web}
+data:
+  key: "value" # old
+`,
+			newYAML: `kind: ConfigMap
+metadata:
+  name: app
+  labels:
+    app: web
+data:
+  key: value # new
+`,
+		},
+		{
+			name: "alias_sites",
+			opts: Options{CollapseAliases: true},
+			oldYAML: `kind: Config
+metadata:
+  name: app
+defaults: &defaults
+  timeout: 30
+staging:
+  <<: *defaults
+production:
+  settings: *defaults
+`,
+			newYAML: `kind: Config
+metadata:
+  name: app
+defaults: &defaults
+  timeout: 60
+staging:
+  <<: *defaults
+production:
+  settings: *defaults
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDocs, newDocs := parseYAML(t, "old.yaml", tt.oldYAML), parseYAML(t, "new.yaml", tt.newYAML)
+			for i := range oldDocs {
+				oldDocs[i].Source = tt.oldSource
+			}
+			for i := range newDocs {
+				newDocs[i].Source = tt.newSource
+			}
+
+			var buf bytes.Buffer
+			if err := compareDocs(t, tt.opts, oldDocs, newDocs).WriteJSON(&buf); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata", "json", tt.name+".json"), buf.Bytes())
+		})
+	}
+}
+
+// TestJSONSchemaVersion fails when a field of the JSON output is removed,
+// renamed or changes type without a JSONSchemaVersion bump. The fields of
+// the current version are recorded in testdata/json/schema.txt; run the
+// tests with -update to record added fields or a new version.
+func TestJSONSchemaVersion(t *testing.T) {
+	path := filepath.Join("testdata", "json", "schema.txt")
+	fields := jsonSchemaFields("", reflect.TypeOf(jsonResult{}))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	recordedVersion, err := strconv.Atoi(strings.TrimPrefix(lines[0], "version "))
+	if err != nil {
+		t.Fatalf("%s: bad version line %q", path, lines[0])
+	}
+	recorded := lines[1:]
+
+	if recordedVersion == JSONSchemaVersion {
+		current := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			current[field] = true
+		}
+		for _, field := range recorded {
+			if !current[field] {
+				t.Errorf("field %q was removed or changed without bumping JSONSchemaVersion", field)
+			}
+		}
+		if t.Failed() {
+			return
+		}
+	}
+
+	want := fmt.Sprintf("version %d\n%s\n", JSONSchemaVersion, strings.Join(fields, "\n"))
+	checkGolden(t, path, []byte(want))
+}
+
+// jsonSchemaFields lists the JSON fields of a type with their types, e.g.
+// ".modified[].changes[].op string"
+func jsonSchemaFields(prefix string, typ reflect.Type) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	var fields []string
+	switch typ.Kind() {
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+			fields = append(fields, jsonSchemaFields(prefix+"."+name, typ.Field(i).Type)...)
+		}
+	case reflect.Slice:
+		fields = append(fields, prefix+" array")
+		fields = append(fields, jsonSchemaFields(prefix+"[]", typ.Elem())...)
+	case reflect.String:
+		fields = append(fields, prefix+" string")
+	case reflect.Int, reflect.Float64:
+		fields = append(fields, prefix+" number")
+	case reflect.Interface:
+		fields = append(fields, prefix+" any")
+	default:
+		fields = append(fields, prefix+" "+typ.Kind().String())
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// checkGolden compares output with a golden file, or rewrites the file
+// with -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update if the change is intended):\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"github.com/tyuhara/yamldiff/internal/textdiff"
+)
+
+// multilineStrings returns the old and new text of a change to a string
+// spanning several lines, such as a config file embedded in a ConfigMap
+func multilineStrings(c Change) (string, string, bool) {
+	oldText, oldIsString := c.OldValue.(string)
+	newText, newIsString := c.NewValue.(string)
+
+	switch c.Op {
+	case OpAdd:
+		return "", newText, newIsString && strings.Contains(newText, "\n")
+	case OpDelete:
+		return oldText, "", oldIsString && strings.Contains(oldText, "\n")
+	case OpModify:
+		return oldText, newText, oldIsString && newIsString &&
+			(strings.Contains(oldText, "\n") || strings.Contains(newText, "\n"))
+	default:
+		return "", "", false
+	}
+}
+
+// maxMultilineHunks is the number of hunks written for a change to a
+// multi-line string. The rest are counted in a closing line.
+const maxMultilineHunks = 20
+
+// renderMultiline writes a change to a multi-line string as a line diff
+// below the field path, up to maxMultilineHunks hunks
+func (t *TextRenderer) renderMultiline(out *errWriter, p palette, c Change, oldText, newText string) {
+	prefix := map[Operation]string{OpAdd: "+", OpDelete: "-", OpModify: "~"}[c.Op]
+	out.printf("  %s %s:%s\n", prefix, c.Path, t.positions(c.OldPosition, c.NewPosition))
+
+	lines := textdiff.Diff(splitText(oldText), splitText(newText))
+	hunks := textdiff.Hunks(lines, t.Context)
+	for i, hunk := range hunks {
+		if i == maxMultilineHunks {
+			out.printf("      %s\n", p.cyan(fmt.Sprintf("... %d more hunks", len(hunks)-i)))
+			break
+		}
+		if c.Op == OpModify {
+			out.printf("      %s\n", p.cyan(hunk.Header()))
+		}
+		t.renderHunkLines(out, p, "      ", hunk.Lines)
+	}
+}
+
+// splitText splits a string into lines, ignoring the final line break
+func splitText(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return parser.SplitLines(strings.TrimSuffix(s, "\n"))
+}
+
+// renderHunkLines writes diff lines with -, + and space markers. With
+// WordDiff, a deleted line directly replaced by an inserted one is written
+// once with the changed words highlighted.
+func (t *TextRenderer) renderHunkLines(out *errWriter, p palette, indent string, lines []textdiff.Line) {
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == textdiff.Equal {
+			out.printf("%s %s\n", indent, lines[i].Text)
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Kind == textdiff.Delete; i++ {
+			deleted = append(deleted, lines[i].Text)
+		}
+		for ; i < len(lines) && lines[i].Kind == textdiff.Insert; i++ {
+			inserted = append(inserted, lines[i].Text)
+		}
+
+		paired := 0
+		if t.WordDiff {
+			paired = min(len(deleted), len(inserted))
+		}
+		for j := 0; j < paired; j++ {
+			if merged, ok := t.wordDiff(p, deleted[j], inserted[j]); ok {
+				out.printf("%s%s%s\n", indent, p.yellow("~"), merged)
+				continue
+			}
+			out.printf("%s%s\n", indent, p.red("-"+deleted[j]))
+			out.printf("%s%s\n", indent, p.green("+"+inserted[j]))
+		}
+		for _, line := range deleted[paired:] {
+			out.printf("%s%s\n", indent, p.red("-"+line))
+		}
+		for _, line := range inserted[paired:] {
+			out.printf("%s%s\n", indent, p.green("+"+line))
+		}
+	}
+}
+
+// wordDiff merges two versions of a line, marking removed words as [-x-]
+// and added words as {+y+}, or colouring them when colour is enabled. It
+// reports false when the lines share no word, as a merged line would then
+// be harder to read than the two lines.
+func (t *TextRenderer) wordDiff(p palette, oldLine, newLine string) (string, bool) {
+	tokens := textdiff.Diff(splitWords(oldLine), splitWords(newLine))
+
+	var b strings.Builder
+	shared := false
+	for i := 0; i < len(tokens); {
+		// Join runs of the same kind so "[-a b-]" isn't split per word
+		kind := tokens[i].Kind
+		var run strings.Builder
+		for ; i < len(tokens) && tokens[i].Kind == kind; i++ {
+			run.WriteString(tokens[i].Text)
+		}
+		text := run.String()
+
+		switch {
+		case kind == textdiff.Delete && t.Color:
+			b.WriteString(p.red(text))
+		case kind == textdiff.Delete:
+			b.WriteString("[-" + text + "-]")
+		case kind == textdiff.Insert && t.Color:
+			b.WriteString(p.green(text))
+		case kind == textdiff.Insert:
+			b.WriteString("{+" + text + "+}")
+		default:
+			shared = shared || strings.TrimSpace(text) != ""
+			b.WriteString(text)
+		}
+	}
+	return b.String(), shared
+}
+
+// splitWords splits a line into alternating runs of whitespace and
+// non-whitespace, so joining the tokens gives back the line
+func splitWords(s string) []string {
+	var tokens []string
+	start, inSpace := 0, false
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != inSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		inSpace = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderMultiline(t *testing.T) {
+	// A large string with one line changed in the middle
+	var large, edited strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&large, "line %d\n", i)
+		if i == 2500 {
+			edited.WriteString("changed\n")
+		} else {
+			fmt.Fprintf(&edited, "line %d\n", i)
+		}
+	}
+	path := Path{}.Child("data").Child("app.conf")
+
+	tests := []struct {
+		name             string
+		op               Operation
+		oldText, newText string
+		context          int
+		want             string
+	}{
+		{
+			name:    "line diff",
+			op:      OpModify,
+			oldText: "a\nb\n",
+			newText: "a\nc\n",
+			context: 3,
+			want:    "  ~ data[\"app.conf\"]:\n      @@ -1,2 +1,2 @@\n       a\n      -b\n      +c\n",
+		},
+		{
+			name:    "added",
+			op:      OpAdd,
+			newText: "a\nb\n",
+			context: 3,
+			want:    "  + data[\"app.conf\"]:\n      +a\n      +b\n",
+		},
+		{
+			name:    "one line changed in a large string",
+			op:      OpModify,
+			oldText: large.String(),
+			newText: edited.String(),
+			context: 0,
+			want: "  ~ data[\"app.conf\"]:\n      @@ -2501 +2501 @@\n" +
+				"      -line 2500\n      +changed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := &TextRenderer{Context: tt.context}
+			c := Change{Op: tt.op, Path: path, OldValue: tt.oldText, NewValue: tt.newText}
+			r.renderMultiline(&errWriter{w: &buf}, newPalette(false), c, tt.oldText, tt.newText)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("renderMultiline() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMultilineHunkLimit(t *testing.T) {
+	// Every tenth line changed gives one hunk per change with context 1
+	var oldText, newText strings.Builder
+	changes := maxMultilineHunks + 5
+	for i := 0; i < changes*10; i++ {
+		fmt.Fprintf(&oldText, "line %d\n", i)
+		if i%10 == 5 {
+			fmt.Fprintf(&newText, "new %d\n", i)
+		} else {
+			fmt.Fprintf(&newText, "line %d\n", i)
+		}
+	}
+
+	var buf bytes.Buffer
+	c := Change{Op: OpModify, Path: Path{}.Child("conf"), OldValue: oldText.String(), NewValue: newText.String()}
+	(&TextRenderer{Context: 1}).renderMultiline(&errWriter{w: &buf}, newPalette(false), c, oldText.String(), newText.String())
+
+	out := buf.String()
+	if got := strings.Count(out, "@@ -"); got != maxMultilineHunks {
+		t.Errorf("wrote %d hunks, want %d", got, maxMultilineHunks)
+	}
+	if !strings.HasSuffix(out, "      ... 5 more hunks\n") {
+		t.Errorf("output should end with the number of hunks left out:\n%s", out)
+	}
+}
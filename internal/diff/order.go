@@ -0,0 +1,216 @@
+package diff
+
+import (
+	"sort"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeOrder selects the order in which the changes of a document are
+// reported
+type ChangeOrder string
+
+const (
+	// OrderDocument follows the key order of the new document as written,
+	// with removed keys after the key that preceded them in the old one
+	OrderDocument ChangeOrder = "document"
+	// OrderAlphabetical sorts mapping keys alphabetically at every level
+	OrderAlphabetical ChangeOrder = "alphabetical"
+)
+
+// rankPart places one path segment among its siblings. Segments missing
+// from the document order fall back to their name.
+type rankPart struct {
+	pos  int
+	name string
+}
+
+// orderChanges sorts changes by the position of their paths in the old and
+// new documents, or with OrderAlphabetical by key name. List elements keep
+// their document order either way. Formatting and comment changes, which
+// are appended after the content changes, are sorted in with them.
+func (e *Engine) orderChanges(changes []Change, oldDoc, newDoc parser.Document) {
+	if len(changes) < 2 {
+		return
+	}
+
+	ranks := make([][]rankPart, len(changes))
+	for i, change := range changes {
+		ranks[i] = documentRank(change.Path, oldDoc.Node, newDoc.Node, e.changeOrder == OrderAlphabetical)
+	}
+	sort.Stable(byRank{changes, ranks})
+}
+
+type byRank struct {
+	changes []Change
+	ranks   [][]rankPart
+}
+
+func (b byRank) Len() int { return len(b.changes) }
+
+func (b byRank) Swap(i, j int) {
+	b.changes[i], b.changes[j] = b.changes[j], b.changes[i]
+	b.ranks[i], b.ranks[j] = b.ranks[j], b.ranks[i]
+}
+
+func (b byRank) Less(i, j int) bool {
+	a, c := b.ranks[i], b.ranks[j]
+	for k := 0; k < len(a) && k < len(c); k++ {
+		if a[k].pos != c[k].pos {
+			return a[k].pos < c[k].pos
+		}
+		if a[k].name != c[k].name {
+			return a[k].name < c[k].name
+		}
+	}
+	return len(a) < len(c)
+}
+
+// documentRank returns the position of every segment of path among its
+// siblings, walking the old and new nodes side by side. With alphabetical,
+// mapping keys are ranked by name alone.
+func documentRank(path Path, oldNode, newNode *yaml.Node, alphabetical bool) []rankPart {
+	rank := make([]rankPart, 0, len(path))
+	for _, seg := range path {
+		oldNode, newNode = resolveAlias(oldNode), resolveAlias(newNode)
+
+		switch {
+		case seg.Kind == IndexSegment:
+			// Elements matched by index keep their index
+			rank = append(rank, rankPart{pos: seg.Index})
+		case seg.Kind == KeySegment && alphabetical:
+			rank = append(rank, rankPart{name: seg.Key})
+		case seg.Kind == KeySegment:
+			order := mergeOrder(mappingKeys(newNode), mappingKeys(oldNode))
+			rank = append(rank, rankPart{pos: indexOf(order, seg.Key, len(order)), name: seg.Key})
+		case seg.Kind == MatchSegment:
+			order := mergeOrder(elementKeys(newNode, seg.MatchField), elementKeys(oldNode, seg.MatchField))
+			rank = append(rank, rankPart{pos: indexOf(order, seg.MatchValue, len(order)), name: seg.MatchValue})
+		}
+		oldNode, newNode = child(oldNode, seg), child(newNode, seg)
+	}
+	return rank
+}
+
+// child returns the node a single path segment points at, or nil
+func child(node *yaml.Node, seg PathSegment) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	switch seg.Kind {
+	case KeySegment:
+		_, value := mappingEntry(node, seg.Key)
+		return value
+	case IndexSegment:
+		if node.Kind != yaml.SequenceNode || seg.Index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[seg.Index]
+	default:
+		return matchElement(node, seg.MatchField, seg.MatchValue)
+	}
+}
+
+// mappingKeys returns the keys of a mapping in source order. Keys brought
+// in by a merge key (<<) take the place of the merge key.
+func mappingKeys(node *yaml.Node) []string {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if !isMergeKey(k) {
+			keys = append(keys, k.Value)
+			continue
+		}
+		v = resolveAlias(v)
+		sources := []*yaml.Node{v}
+		if v != nil && v.Kind == yaml.SequenceNode {
+			sources = v.Content
+		}
+		for _, source := range sources {
+			keys = append(keys, mappingKeys(source)...)
+		}
+	}
+	return keys
+}
+
+// elementKeys returns the merge key values of the elements of a sequence
+// in source order
+func elementKeys(node *yaml.Node, field string) []string {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var keys []string
+	for _, elem := range node.Content {
+		if _, v := mappingEntry(elem, field); v != nil {
+			if v = resolveAlias(v); v != nil && v.Kind == yaml.ScalarNode {
+				keys = append(keys, v.Value)
+			}
+		}
+	}
+	return keys
+}
+
+// mergeOrder returns the new keys in order, with every key that only
+// exists in the old list placed after the key preceding it there
+func mergeOrder(newKeys, oldKeys []string) []string {
+	inNew := make(map[string]bool, len(newKeys))
+	for _, key := range newKeys {
+		inNew[key] = true
+	}
+
+	// Old-only keys grouped by the nearest preceding key shared with the
+	// new list, and those before any shared key
+	var before []string
+	after := make(map[string][]string)
+	prev, shared := "", false
+	for _, key := range oldKeys {
+		switch {
+		case inNew[key]:
+			prev, shared = key, true
+		case shared:
+			after[prev] = append(after[prev], key)
+		default:
+			before = append(before, key)
+		}
+	}
+
+	order := make([]string, 0, len(newKeys)+len(oldKeys))
+	order = append(order, before...)
+	for _, key := range newKeys {
+		order = append(order, key)
+		order = append(order, after[key]...)
+	}
+	return order
+}
+
+func indexOf(list []string, s string, missing int) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return missing
+}
+
+// sortedMapKeys returns the keys of both maps in alphabetical order
+func sortedMapKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderChanges(t *testing.T) {
+	oldYAML := `kind: ConfigMap
+metadata:
+  name: app
+data:
+  zeta: 1 # z
+  alpha: 1 # a
+  beta: 1
+  list:
+  - a
+  - b
+  - c
+`
+	newYAML := `kind: ConfigMap
+metadata:
+  name: app
+data:
+  zeta: 2 # Z
+  alpha: 1 # A
+  gamma: 1
+  list:
+  - a
+  - x
+  - y
+`
+	tests := []struct {
+		order ChangeOrder
+		want  []string
+	}{
+		{OrderDocument, []string{
+			"~ data.zeta: 1 → 2",
+			`# data.zeta: line comment "# z" → "# Z"`,
+			`# data.alpha: line comment "# a" → "# A"`,
+			"- data.beta: 1",
+			"+ data.gamma: 1",
+			"~ data.list[1]: b → x",
+			"~ data.list[2]: c → y",
+		}},
+		{OrderAlphabetical, []string{
+			`# data.alpha: line comment "# a" → "# A"`,
+			"- data.beta: 1",
+			"+ data.gamma: 1",
+			"~ data.list[1]: b → x",
+			"~ data.list[2]: c → y",
+			"~ data.zeta: 1 → 2",
+			`# data.zeta: line comment "# z" → "# Z"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			result := compareYAML(t, Options{ChangeOrder: tt.order, DiffComments: true}, oldYAML, newYAML)
+			var got []string
+			for _, change := range result.Modified["ConfigMap/app"].Changes {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// locateChanges fills in the source positions of changes from the nodes of
+// the old and new documents
+func locateChanges(changes []Change, oldDoc, newDoc parser.Document) {
+	for i := range changes {
+		change := &changes[i]
+		if change.Op != OpAdd {
+			change.OldPosition = oldDoc.NodePosition(locate(oldDoc.Node, change.Path))
+		}
+		if change.Op != OpDelete {
+			change.NewPosition = newDoc.NodePosition(locate(newDoc.Node, change.Path))
+		}
+	}
+}
+
+// locate returns the node a path points at: the key node for mapping
+// entries and the element node for sequence elements. It returns nil when
+// the path does not exist.
+func locate(root *yaml.Node, path Path) *yaml.Node {
+	if root == nil {
+		return nil
+	}
+	node := root
+	for i, seg := range path {
+		node = resolveAlias(node)
+		if node == nil {
+			return nil
+		}
+
+		switch seg.Kind {
+		case KeySegment:
+			key, value := mappingEntry(node, seg.Key)
+			if key == nil {
+				return nil
+			}
+			if i == len(path)-1 {
+				return key
+			}
+			node = value
+		case IndexSegment:
+			if node.Kind != yaml.SequenceNode || seg.Index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[seg.Index]
+		case MatchSegment:
+			node = matchElement(node, seg.MatchField, seg.MatchValue)
+		}
+	}
+	return node
+}
+
+// mappingEntry returns the key and value nodes of a mapping entry, looking
+// through merge keys (<<) when the key is not set directly
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if isMergeKey(k) {
+			merges = append(merges, v)
+			continue
+		}
+		if k.Value == key {
+			return k, v
+		}
+	}
+
+	for _, merge := range merges {
+		merge = resolveAlias(merge)
+		sources := []*yaml.Node{merge}
+		if merge != nil && merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, source := range sources {
+			if k, v := mappingEntry(source, key); k != nil {
+				return k, v
+			}
+		}
+	}
+	return nil, nil
+}
+
+// matchElement returns the sequence element whose field has the given value
+func matchElement(node *yaml.Node, field, value string) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, elem := range node.Content {
+		if _, v := mappingEntry(elem, field); v != nil {
+			if v = resolveAlias(v); v != nil && v.Kind == yaml.ScalarNode && v.Value == value {
+				return elem
+			}
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && (node.Tag == "" || node.Tag == "!!merge")
+}
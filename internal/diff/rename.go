@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// DefaultRenameThreshold is the similarity a deleted and an added document
+// need to be paired as a rename when Options.RenameThreshold is not set
+const DefaultRenameThreshold = 0.5
+
+// RenamedDoc represents a deleted and an added document that are similar
+// enough to be reported as one document whose identifier changed
+type RenamedDoc struct {
+	OldKey string
+	NewKey string
+	Old    parser.Document
+	New    parser.Document
+	// Similarity is the share of leaf values both documents have in
+	// common, from 0 to 1, not counting the identifying fields
+	Similarity float64
+	Changes    []Change
+}
+
+// Diffs returns the changes rendered as one-line text diffs
+func (r RenamedDoc) Diffs() []string {
+	return ModifiedDoc{Changes: r.Changes}.Diffs()
+}
+
+type renameCandidate struct {
+	oldKey, newKey string
+	similarity     float64
+}
+
+// identityLeaves are the leaf paths that identify a Kubernetes object
+// rather than describe it. Every ConfigMap of a namespace shares most of
+// them, so they are left out of the similarity.
+var identityLeaves = map[string]bool{
+	".apiVersion":         true,
+	".kind":               true,
+	".metadata.name":      true,
+	".metadata.namespace": true,
+}
+
+// detectRenames pairs deleted and added documents of the same kind and
+// apiVersion by similarity, most similar first, and moves the pairs from
+// Deleted and Added to Renamed
+func (e *Engine) detectRenames(r *Result) {
+	if len(r.Added) == 0 || len(r.Deleted) == 0 {
+		return
+	}
+
+	oldLeaves := make(map[string]map[string]string, len(r.Deleted))
+	for key, doc := range r.Deleted {
+		oldLeaves[key] = leafValues(doc.Content)
+	}
+	newLeaves := make(map[string]map[string]string, len(r.Added))
+	for key, doc := range r.Added {
+		newLeaves[key] = leafValues(doc.Content)
+	}
+
+	var candidates []renameCandidate
+	for _, oldKey := range sortedKeys(r.Deleted) {
+		for _, newKey := range sortedKeys(r.Added) {
+			if !sameKind(r.Deleted[oldKey].Content, r.Added[newKey].Content) {
+				continue
+			}
+			s := similarity(oldLeaves[oldKey], newLeaves[newKey])
+			if s >= e.renameThreshold {
+				candidates = append(candidates, renameCandidate{oldKey, newKey, s})
+			}
+		}
+	}
+	// Stable sort keeps the key order among equally similar pairs
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	for _, c := range candidates {
+		oldDoc, oldOK := r.Deleted[c.oldKey]
+		newDoc, newOK := r.Added[c.newKey]
+		if !oldOK || !newOK {
+			// Already paired with a more similar document
+			continue
+		}
+		delete(r.Deleted, c.oldKey)
+		delete(r.Added, c.newKey)
+
+		changes, _ := e.filterIgnored(e.CompareValues(nil, oldDoc.Content, newDoc.Content))
+		if e.collapseAliases {
+			changes = e.foldAliases(changes, oldDoc, newDoc)
+		}
+		locateChanges(changes, oldDoc, newDoc)
+		e.orderChanges(changes, oldDoc, newDoc)
+		r.Renamed[c.newKey] = RenamedDoc{
+			OldKey:     c.oldKey,
+			NewKey:     c.newKey,
+			Old:        e.withoutIgnored(oldDoc),
+			New:        e.withoutIgnored(newDoc),
+			Similarity: c.similarity,
+			Changes:    changes,
+		}
+	}
+}
+
+// sameKind reports whether two documents have the same kind and apiVersion.
+// Documents without them, such as non-Kubernetes files, match each other.
+func sameKind(a, b interface{}) bool {
+	for _, field := range []string{"kind", "apiVersion"} {
+		if fmt.Sprint(topLevelValue(a, field)) != fmt.Sprint(topLevelValue(b, field)) {
+			return false
+		}
+	}
+	return true
+}
+
+func topLevelValue(content interface{}, key string) interface{} {
+	if m, ok := content.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+// similarity returns the Dice coefficient of two sets of leaf values.
+// Documents with nothing but identifying fields have nothing to compare
+// and are not similar.
+func similarity(a, b map[string]string) float64 {
+	if len(a)+len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for path, value := range a {
+		if other, ok := b[path]; ok && other == value {
+			common++
+		}
+	}
+	return float64(2*common) / float64(len(a)+len(b))
+}
+
+// leafValues flattens a document into its scalar values by path, leaving
+// out identityLeaves. Empty mappings and sequences count as values too.
+func leafValues(content interface{}) map[string]string {
+	leaves := make(map[string]string)
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if len(val) == 0 {
+				leaves[path] = "{}"
+			}
+			for k, item := range val {
+				walk(path+"."+k, item)
+			}
+		case []interface{}:
+			if len(val) == 0 {
+				leaves[path] = "[]"
+			}
+			for i, item := range val {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		default:
+			if !identityLeaves[path] {
+				leaves[path] = fmt.Sprintf("%T:%v", v, v)
+			}
+		}
+	}
+	walk("", content)
+	return leaves
+}
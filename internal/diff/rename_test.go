@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectRenames(t *testing.T) {
+	oldYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: alpha
+  namespace: prod
+data:
+  color: red
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: prod
+data:
+  app: x
+`
+	newYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: beta
+  namespace: prod
+data:
+  size: large
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-v2
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+data:
+  app: x
+`
+	result := compareYAML(t, Options{DetectRenames: true}, oldYAML, newYAML)
+
+	renamed := make(map[string]string)
+	for _, doc := range result.Renamed {
+		renamed[doc.OldKey] = doc.NewKey
+	}
+	// ConfigMaps sharing only their namespace aren't paired, nor are
+	// documents of different kinds with the same content
+	want := map[string]string{"Deployment/prod/web": "Deployment/prod/web-v2"}
+	if !reflect.DeepEqual(renamed, want) {
+		t.Errorf("renamed = %v, want %v", renamed, want)
+	}
+	if got := result.Renamed["Deployment/prod/web-v2"].Similarity; got != 2.0/3 {
+		t.Errorf("similarity = %v, want %v", got, 2.0/3)
+	}
+
+	added, deleted := sortedKeys(result.Added), sortedKeys(result.Deleted)
+	if !reflect.DeepEqual(added, []string{"ConfigMap/prod/app", "ConfigMap/prod/beta"}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(deleted, []string{"ConfigMap/prod/alpha", "Service/prod/app"}) {
+		t.Errorf("deleted = %v", deleted)
+	}
+}
+
+func TestLeafValues(t *testing.T) {
+	content := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "prod",
+			"labels":    map[string]interface{}{"app": "web"},
+		},
+		"data": map[string]interface{}{},
+	}
+	want := map[string]string{
+		".metadata.labels.app": "string:web",
+		".data":                "{}",
+	}
+	if got := leafValues(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("leafValues() = %v, want %v", got, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]string
+		want float64
+	}{
+		{"nothing to compare", nil, nil, 0},
+		{"identical", map[string]string{"a": "1"}, map[string]string{"a": "1"}, 1},
+		{"half", map[string]string{"a": "1", "b": "2"}, map[string]string{"a": "1", "b": "3"}, 0.5},
+		{"disjoint", map[string]string{"a": "1"}, map[string]string{"b": "1"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("similarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
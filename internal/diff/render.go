@@ -0,0 +1,342 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// Renderer writes a Result to an io.Writer
+type Renderer interface {
+	Render(w io.Writer, r *Result) error
+}
+
+// TextRenderer renders the human-readable diff output
+type TextRenderer struct {
+	// Verbose prints the full content of added and deleted documents
+	Verbose bool
+	// Positions appends file:line:column locations to documents and changes
+	Positions bool
+	// Color enables ANSI colour codes
+	Color bool
+	// Format selects how changes of modified and renamed documents are
+	// shown; empty means FormatFlat
+	Format Format
+	// Context is the number of unchanged lines around each hunk of the
+	// unified and side-by-side formats
+	Context int
+	// Width is the total width of the side-by-side format; 0 means
+	// DefaultWidth
+	Width int
+	// WordDiff highlights the changed words of a replaced line instead of
+	// showing the old and new line
+	WordDiff bool
+}
+
+// SummaryRenderer renders only the number of added, deleted and modified
+// documents
+type SummaryRenderer struct {
+	// Compact prints the one-line summary used by verbose output
+	Compact bool
+	// Color enables ANSI colour codes
+	Color bool
+}
+
+// JSONRenderer renders the versioned JSON output
+type JSONRenderer struct{}
+
+// ColorEnabled reports whether colour output should be used for w. Only
+// terminals get colour, and NO_COLOR or TERM=dumb turn it off.
+func ColorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// Print prints the diff result to w
+func (r *Result) Print(w io.Writer, verbose bool) error {
+	return (&TextRenderer{Verbose: verbose, Color: ColorEnabled(w)}).Render(w, r)
+}
+
+// PrintSummary prints a summary of changes to w
+func (r *Result) PrintSummary(w io.Writer) error {
+	return (&SummaryRenderer{Color: ColorEnabled(w)}).Render(w, r)
+}
+
+// PrintSummaryCompact prints a compact summary suitable for verbose output
+func (r *Result) PrintSummaryCompact(w io.Writer) error {
+	return (&SummaryRenderer{Compact: true}).Render(w, r)
+}
+
+// Render writes the diff result to w
+func (t *TextRenderer) Render(w io.Writer, r *Result) error {
+	out := &errWriter{w: w}
+	p := newPalette(t.Color)
+
+	if !t.Verbose {
+		// Non-verbose: show key names only
+		// Print added documents
+		keys := sortedKeys(r.Added)
+		for _, key := range keys {
+			out.printf("%s %s%s\n", p.green("+ Added:"), p.cyan(key),
+				t.positions(parser.Position{}, r.Added[key].Position()))
+		}
+
+		// Print deleted documents
+		keys = sortedKeys(r.Deleted)
+		for _, key := range keys {
+			out.printf("%s %s%s\n", p.red("- Deleted:"), p.cyan(key),
+				t.positions(r.Deleted[key].Position(), parser.Position{}))
+		}
+
+		// Print moved and renamed documents
+		t.renderMoved(out, p, r)
+		t.renderRenamed(out, p, r)
+
+		// Print modified and reformatted documents
+		t.renderModified(out, p, r)
+		t.renderReformatted(out, p, r)
+
+		// Print summary
+		if out.err != nil {
+			return out.err
+		}
+		return (&SummaryRenderer{Color: t.Color}).Render(w, r)
+	}
+
+	// Verbose: show summary first, then full document content with diff-style prefixes
+	if err := (&SummaryRenderer{Compact: true}).Render(w, r); err != nil {
+		return err
+	}
+
+	// Print added documents with "+" prefix
+	keys := sortedKeys(r.Added)
+	for _, key := range keys {
+		doc := r.Added[key]
+		lines := parser.SplitLines(doc.Raw)
+		for _, line := range lines {
+			if len(line) > 0 {
+				out.printf("%s\n", p.green("+ "+line))
+			}
+		}
+	}
+
+	// Print deleted documents with "-" prefix
+	keys = sortedKeys(r.Deleted)
+	for _, key := range keys {
+		doc := r.Deleted[key]
+		lines := parser.SplitLines(doc.Raw)
+		for _, line := range lines {
+			if len(line) > 0 {
+				out.printf("%s\n", p.red("- "+line))
+			}
+		}
+	}
+
+	// Print moved and renamed documents
+	t.renderMoved(out, p, r)
+	t.renderRenamed(out, p, r)
+
+	// Print modified and reformatted documents
+	t.renderModified(out, p, r)
+	t.renderReformatted(out, p, r)
+
+	return out.err
+}
+
+func (t *TextRenderer) renderMoved(out *errWriter, p palette, r *Result) {
+	for _, key := range sortedKeysMoved(r.Moved) {
+		moved := r.Moved[key]
+		out.printf("%s %s (%s → %s)\n", p.blue("> Moved:"), p.cyan(key), moved.Old.Source, moved.New.Source)
+	}
+}
+
+func (t *TextRenderer) renderRenamed(out *errWriter, p palette, r *Result) {
+	for _, key := range sortedKeysRenamed(r.Renamed) {
+		renamed := r.Renamed[key]
+		out.printf("%s %s → %s (%.0f%% similar)%s\n", p.magenta("> Renamed:"), p.cyan(renamed.OldKey), p.cyan(renamed.NewKey),
+			renamed.Similarity*100, t.positions(renamed.Old.Position(), renamed.New.Position()))
+		t.renderChanges(out, p, renamed.Old, renamed.New, renamed.Changes)
+		out.printf("\n")
+	}
+}
+
+func (t *TextRenderer) renderModified(out *errWriter, p palette, r *Result) {
+	keys := sortedKeysModified(r.Modified)
+	for _, key := range keys {
+		mod := r.Modified[key]
+		out.printf("%s %s%s\n", p.yellow("~ Modified:"), p.cyan(key),
+			t.positions(mod.Old.Position(), mod.New.Position()))
+		t.renderChanges(out, p, mod.Old, mod.New, mod.Changes)
+		out.printf("\n")
+	}
+}
+
+// renderReformatted lists the formatting and comment changes of documents
+// whose content is unchanged. The line diff formats would show nothing, as they compare
+// normalised text.
+func (t *TextRenderer) renderReformatted(out *errWriter, p palette, r *Result) {
+	for _, key := range sortedKeysModified(r.Reformatted) {
+		mod := r.Reformatted[key]
+		out.printf("%s %s%s\n", p.blue("≈ Reformatted:"), p.cyan(key),
+			t.positions(mod.Old.Position(), mod.New.Position()))
+		for _, change := range mod.Changes {
+			out.printf("  %s%s\n", change, t.positions(change.OldPosition, change.NewPosition))
+		}
+		out.printf("\n")
+	}
+}
+
+// renderChanges writes the changes between two documents in the selected
+// format
+func (t *TextRenderer) renderChanges(out *errWriter, p palette, oldDoc, newDoc parser.Document, changes []Change) {
+	if t.Format == FormatUnified || t.Format == FormatSideBySide {
+		t.renderLines(out, p, oldDoc, newDoc)
+		// The line diff compares normalised text, so formatting and
+		// comment changes are listed after it
+		for _, change := range changes {
+			if !change.IsContent() {
+				out.printf("  %s%s\n", change, t.positions(change.OldPosition, change.NewPosition))
+			}
+		}
+		return
+	}
+	for _, change := range changes {
+		if oldText, newText, ok := multilineStrings(change); ok {
+			t.renderMultiline(out, p, change, oldText, newText)
+		} else {
+			out.printf("  %s%s\n", change, t.positions(change.OldPosition, change.NewPosition))
+		}
+		t.renderAliasSites(out, p, change)
+	}
+}
+
+// renderAliasSites lists the alias sites a change was folded from
+func (t *TextRenderer) renderAliasSites(out *errWriter, p palette, c Change) {
+	if len(c.AliasSites) == 0 {
+		return
+	}
+	out.printf("    %s\n", p.blue("↳ also at:"))
+	for _, site := range c.AliasSites {
+		out.printf("        %s (*%s)\n", site.Path, site.Anchor)
+	}
+}
+
+// positions formats the old and new locations as a suffix, or returns ""
+// when positions are disabled
+func (t *TextRenderer) positions(oldPos, newPos parser.Position) string {
+	if !t.Positions {
+		return ""
+	}
+	switch {
+	case !oldPos.IsZero() && !newPos.IsZero():
+		return fmt.Sprintf(" (%s → %s)", oldPos, newPos)
+	case !oldPos.IsZero():
+		return fmt.Sprintf(" (%s)", oldPos)
+	case !newPos.IsZero():
+		return fmt.Sprintf(" (%s)", newPos)
+	default:
+		return ""
+	}
+}
+
+// Render writes the summary to w
+func (s *SummaryRenderer) Render(w io.Writer, r *Result) error {
+	out := &errWriter{w: w}
+
+	if s.Compact {
+		out.printf("Summary\n")
+		out.printf("%d added, %d deleted, %d modified", len(r.Added), len(r.Deleted), len(r.Modified))
+		if len(r.Moved) > 0 {
+			out.printf(", %d moved", len(r.Moved))
+		}
+		if len(r.Renamed) > 0 {
+			out.printf(", %d renamed", len(r.Renamed))
+		}
+		if len(r.Reformatted) > 0 {
+			out.printf(", %d reformatted", len(r.Reformatted))
+		}
+		out.printf("\n")
+		return out.err
+	}
+
+	p := newPalette(s.Color)
+	out.printf("\n%s\n", p.bold("Summary:"))
+	out.printf("  %s: %d\n", p.green("Added"), len(r.Added))
+	out.printf("  %s: %d\n", p.red("Deleted"), len(r.Deleted))
+	out.printf("  %s: %d\n", p.yellow("Modified"), len(r.Modified))
+	if len(r.Moved) > 0 {
+		out.printf("  %s: %d\n", p.blue("Moved"), len(r.Moved))
+	}
+	if len(r.Renamed) > 0 {
+		out.printf("  %s: %d\n", p.magenta("Renamed"), len(r.Renamed))
+	}
+	if len(r.Reformatted) > 0 {
+		out.printf("  %s: %d\n", p.blue("Reformatted"), len(r.Reformatted))
+	}
+	return out.err
+}
+
+// Render writes the JSON document to w
+func (JSONRenderer) Render(w io.Writer, r *Result) error {
+	return r.WriteJSON(w)
+}
+
+// palette holds the colour functions of a single render
+type palette struct {
+	red     func(a ...interface{}) string
+	green   func(a ...interface{}) string
+	yellow  func(a ...interface{}) string
+	blue    func(a ...interface{}) string
+	magenta func(a ...interface{}) string
+	cyan    func(a ...interface{}) string
+	bold    func(a ...interface{}) string
+}
+
+func newPalette(enabled bool) palette {
+	sprint := func(attrs ...color.Attribute) func(a ...interface{}) string {
+		c := color.New(attrs...)
+		if enabled {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+		return c.SprintFunc()
+	}
+
+	return palette{
+		red:     sprint(color.FgRed),
+		green:   sprint(color.FgGreen),
+		yellow:  sprint(color.FgYellow),
+		blue:    sprint(color.FgBlue),
+		magenta: sprint(color.FgMagenta),
+		cyan:    sprint(color.FgCyan),
+		bold:    sprint(color.Bold),
+	}
+}
+
+// errWriter remembers the first write error so renderers can print freely
+// and check once at the end
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultListKeys are the merge keys used to match elements of well-known
+// Kubernetes lists. Entries passed in Options.ListKeys take precedence.
+var DefaultListKeys = map[string]string{
+	"containers":          "name",
+	"initContainers":      "name",
+	"ephemeralContainers": "name",
+	"env":                 "name",
+	"volumes":             "name",
+	"ports":               "containerPort",
+}
+
+// compareSequences compares two sequences element by element. Elements are
+// matched by their merge key when one is configured for the path and every
+// element carries a unique value for it; otherwise they are matched by index.
+func (e *Engine) compareSequences(path Path, oldList, newList []interface{}) []Change {
+	if field := e.listKey(path); field != "" {
+		oldKeys, oldOK := keyedElements(oldList, field)
+		newKeys, newOK := keyedElements(newList, field)
+		if oldOK && newOK {
+			return e.compareKeyedSequences(path, field, oldList, newList, oldKeys, newKeys)
+		}
+	}
+
+	var changes []Change
+	for i := 0; i < len(oldList) || i < len(newList); i++ {
+		elemPath := path.Element(i)
+		switch {
+		case i >= len(oldList):
+			changes = append(changes, added(elemPath, newList[i]))
+		case i >= len(newList):
+			changes = append(changes, deleted(elemPath, oldList[i]))
+		default:
+			changes = append(changes, e.CompareValues(elemPath, oldList[i], newList[i])...)
+		}
+	}
+	return changes
+}
+
+func (e *Engine) compareKeyedSequences(path Path, field string, oldList, newList []interface{}, oldKeys, newKeys []string) []Change {
+	newIndex := make(map[string]int, len(newKeys))
+	for i, key := range newKeys {
+		newIndex[key] = i
+	}
+	oldIndex := make(map[string]int, len(oldKeys))
+	for i, key := range oldKeys {
+		oldIndex[key] = i
+	}
+
+	var changes []Change
+	for i, key := range oldKeys {
+		elemPath := path.Match(field, key)
+		if j, ok := newIndex[key]; ok {
+			changes = append(changes, e.CompareValues(elemPath, oldList[i], newList[j])...)
+		} else {
+			changes = append(changes, deleted(elemPath, oldList[i]))
+		}
+	}
+	for j, key := range newKeys {
+		if _, ok := oldIndex[key]; !ok {
+			changes = append(changes, added(path.Match(field, key), newList[j]))
+		}
+	}
+	return changes
+}
+
+// keyedElements returns the merge key value of every element, or false if
+// any element is not a mapping, lacks a scalar value for the field, or
+// shares its value with another element
+func keyedElements(list []interface{}, field string) ([]string, bool) {
+	keys := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, elem := range list {
+		m, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok := m[field]
+		if !ok || value == nil {
+			return nil, false
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, false
+		}
+		key := fmt.Sprintf("%v", value)
+		if seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// listKey returns the merge key configured for the sequence at path. A
+// pattern matches when it equals the trailing mapping keys of the path, so
+// "containers" matches spec.template.spec.containers and
+// "containers.ports" matches the ports of any container. The longest
+// matching pattern wins.
+func (e *Engine) listKey(path Path) string {
+	keys := path.Keys()
+
+	patterns := make([]string, 0, len(e.listKeys))
+	for pattern := range e.listKeys {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	for _, pattern := range patterns {
+		if hasKeySuffix(keys, strings.Split(pattern, ".")) {
+			return e.listKeys[pattern]
+		}
+	}
+	return ""
+}
+
+func hasKeySuffix(keys, suffix []string) bool {
+	if len(suffix) > len(keys) {
+		return false
+	}
+	offset := len(keys) - len(suffix)
+	for i, key := range suffix {
+		if keys[offset+i] != key {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+	"github.com/tyuhara/yamldiff/internal/textdiff"
+	"golang.org/x/term"
+)
+
+// Format selects how TextRenderer shows the changes of a modified document
+type Format string
+
+const (
+	// FormatFlat lists one "~ path: old → new" line per changed field
+	FormatFlat Format = "flat"
+	// FormatUnified shows git-style hunks of the document text
+	FormatUnified Format = "unified"
+	// FormatSideBySide shows the old and new document text in two columns
+	FormatSideBySide Format = "side-by-side"
+)
+
+// DefaultContext is the number of unchanged lines around each hunk
+const DefaultContext = 3
+
+// DefaultWidth is the side-by-side width used when the terminal width is
+// unknown
+const DefaultWidth = 160
+
+// TerminalWidth returns the width of the terminal w writes to, from
+// COLUMNS or the terminal itself, or 0 if it is unknown
+func TerminalWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+// renderLines writes the line diff of two documents in the unified or
+// side-by-side format
+func (t *TextRenderer) renderLines(out *errWriter, p palette, oldDoc, newDoc parser.Document) {
+	lines := textdiff.Diff(parser.SplitLines(strings.TrimSuffix(oldDoc.Raw, "\n")),
+		parser.SplitLines(strings.TrimSuffix(newDoc.Raw, "\n")))
+
+	for _, hunk := range textdiff.Hunks(lines, t.Context) {
+		out.printf("  %s\n", p.cyan(hunk.Header()))
+		if t.Format == FormatSideBySide {
+			t.renderSideBySide(out, p, hunk.Lines)
+			continue
+		}
+		t.renderHunkLines(out, p, "  ", hunk.Lines)
+	}
+}
+
+// renderSideBySide writes hunk lines in two columns. Deleted and inserted
+// lines of one change are paired up row by row, as with sdiff.
+func (t *TextRenderer) renderSideBySide(out *errWriter, p palette, lines []textdiff.Line) {
+	width := t.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	// Two columns separated by " x " after the two-space indent
+	column := (width - 2 - 3) / 2
+	if column < 10 {
+		column = 10
+	}
+
+	row := func(left, marker, right string, colorLeft, colorRight func(a ...interface{}) string) {
+		l := fit(left, column)
+		out.printf("  %s %s %s\n", colorLeft(l), marker, colorRight(strings.TrimRight(fit(right, column), " ")))
+	}
+	plain := func(a ...interface{}) string { return fmt.Sprint(a...) }
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == textdiff.Equal {
+			row(lines[i].Text, " ", lines[i].Text, plain, plain)
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Kind == textdiff.Delete; i++ {
+			deleted = append(deleted, lines[i].Text)
+		}
+		for ; i < len(lines) && lines[i].Kind == textdiff.Insert; i++ {
+			inserted = append(inserted, lines[i].Text)
+		}
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			switch {
+			case j < len(deleted) && j < len(inserted):
+				row(deleted[j], p.yellow("|"), inserted[j], p.red, p.green)
+			case j < len(deleted):
+				row(deleted[j], p.red("<"), "", p.red, plain)
+			default:
+				row("", p.green(">"), inserted[j], plain, p.green)
+			}
+		}
+	}
+}
+
+// fit pads or truncates s to exactly width characters
+func fit(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
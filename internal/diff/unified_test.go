@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLineViewsLeaveOutIgnoredFields(t *testing.T) {
+	oldYAML := `kind: Deployment
+metadata:
+  name: web
+  generation: 1
+spec:
+  replicas: 2
+  selector:
+    app: web
+  strategy: Recreate
+  minReadySeconds: 10
+  revisionHistoryLimit: 5
+  paused: false
+status:
+  readyReplicas: 2
+`
+	newYAML := `kind: Deployment
+metadata:
+  name: NAME
+  generation: 7
+spec:
+  replicas: 3
+  selector:
+    app: web
+  strategy: Recreate
+  minReadySeconds: 10
+  revisionHistoryLimit: 5
+  paused: false
+status:
+  readyReplicas: 0
+`
+	rules, err := ParseIgnoreRules([]string{"status", "metadata.generation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A modified document, and a renamed one
+	for _, name := range []string{"web", "web-v2"} {
+		result := compareYAML(t, Options{Ignore: rules, DetectRenames: true}, oldYAML, strings.Replace(newYAML, "NAME", name, 1))
+		if len(result.Modified)+len(result.Renamed) != 1 {
+			t.Fatalf("%s: got %d modified and %d renamed documents, want one", name, len(result.Modified), len(result.Renamed))
+		}
+		for _, format := range []Format{FormatUnified, FormatSideBySide} {
+			var buf bytes.Buffer
+			if err := (&TextRenderer{Format: format, Context: DefaultContext}).Render(&buf, result); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if strings.Contains(out, "generation") || strings.Contains(out, "readyReplicas") {
+				t.Errorf("%s output shows ignored fields:\n%s", format, out)
+			}
+			if !strings.Contains(out, "replicas: 3") {
+				t.Errorf("%s output misses the replicas change:\n%s", format, out)
+			}
+		}
+	}
+}
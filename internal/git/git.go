@@ -0,0 +1,110 @@
+// Package git reads files from revisions of the local repository through
+// git plumbing commands, without touching the working tree or the network.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Object types reported by ObjectType
+const (
+	Blob = "blob"
+	Tree = "tree"
+)
+
+// ParseRange splits a revision range such as main..HEAD into its two
+// revisions. An omitted side means HEAD, as with git diff.
+func ParseRange(s string) (string, string, bool) {
+	base, head, ok := strings.Cut(s, "..")
+	if !ok || strings.HasPrefix(head, ".") || strings.HasPrefix(head, "/") || strings.HasSuffix(base, "/") {
+		return "", "", false
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+	return base, head, true
+}
+
+// VerifyRevision checks that rev names a commit of the local repository
+func VerifyRevision(rev string) error {
+	if _, err := run("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}"); err != nil {
+		return fmt.Errorf("unknown git revision %q", rev)
+	}
+	return nil
+}
+
+// ObjectType returns Blob or Tree for a path at a revision, or "" when the
+// path does not exist there. path is relative to the current directory.
+func ObjectType(rev, path string) (string, error) {
+	if err := VerifyRevision(rev); err != nil {
+		return "", err
+	}
+	out, err := run("cat-file", "-t", object(rev, path))
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListFiles returns the files below path at a revision, relative to the
+// current directory and in lexical order
+func ListFiles(rev, path string) ([]string, error) {
+	out, err := run("ls-tree", "-r", "-z", "--name-only", rev, "--", relative(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// ReadFile returns the contents of a file at a revision
+func ReadFile(rev, path string) ([]byte, error) {
+	return run("cat-file", "blob", object(rev, path))
+}
+
+// object names path at rev, relative to the current directory
+func object(rev, path string) string {
+	return rev + ":./" + relative(path)
+}
+
+// relative makes absolute paths relative to the current directory, which
+// is how git resolves paths given to plumbing commands, and uses slashes
+func relative(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := filepath.Abs("."); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+func run(args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// NotExistError is returned by ParsePath when the path does not exist at
+// the revision, or no file matches the glob there
+type NotExistError struct {
+	Path string
+	Rev  string
+}
+
+func (e *NotExistError) Error() string {
+	if parser.IsGlob(e.Path) {
+		return fmt.Sprintf("no files match %s at %s", e.Path, e.Rev)
+	}
+	return fmt.Sprintf("%s does not exist at %s", e.Path, e.Rev)
+}
+
+// ParsePath is like parser.ParsePath but reads the file, directory or glob
+// at a revision. Documents are labelled REV:PATH. A missing path gives a
+// *NotExistError, so callers can tell a file added or deleted between two
+// revisions from other errors; an unknown revision is an error of its own.
+func ParsePath(rev, p string, format parser.Format) ([]parser.Document, error) {
+	typ, err := ObjectType(rev, p)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case typ == Blob:
+		return parseFile(rev, relative(p), "", format)
+	case typ == Tree:
+		root := relative(p)
+		files, err := ListFiles(rev, root)
+		if err != nil {
+			return nil, err
+		}
+		var matches []string
+		for _, file := range files {
+			if format.Matches(file) && !hidden(root, file) {
+				matches = append(matches, file)
+			}
+		}
+		return parseFiles(rev, root, matches, format)
+	case parser.IsGlob(p):
+		pattern := relative(p)
+		root := parser.GlobRoot(pattern)
+		if typ, err := ObjectType(rev, root); err != nil || typ != Tree {
+			return nil, &NotExistError{Path: p, Rev: rev}
+		}
+		files, err := ListFiles(rev, root)
+		if err != nil {
+			return nil, err
+		}
+		var matches []string
+		for _, file := range files {
+			if parser.MatchGlob(pattern, file) {
+				matches = append(matches, file)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, &NotExistError{Path: p, Rev: rev}
+		}
+		return parseFiles(rev, root, matches, format)
+	default:
+		return nil, &NotExistError{Path: p, Rev: rev}
+	}
+}
+
+func parseFiles(rev, root string, files []string, format parser.Format) ([]parser.Document, error) {
+	var docs []parser.Document
+	for _, file := range files {
+		fileDocs, err := parseFile(rev, file, relTo(root, file), format)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+	}
+	return docs, nil
+}
+
+func parseFile(rev, file, source string, format parser.Format) ([]parser.Document, error) {
+	name := rev + ":" + file
+	data, err := ReadFile(rev, file)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := parser.ParseBytes(data, name, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for i := range docs {
+		docs[i].Source = source
+	}
+	return docs, nil
+}
+
+// hidden reports whether file lies in a hidden directory below root, which
+// parser.ParsePath skips as well
+func hidden(root, file string) bool {
+	for _, dir := range strings.Split(path.Dir(relTo(root, file)), "/") {
+		if strings.HasPrefix(dir, ".") && dir != "." && dir != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// relTo returns a slash-separated file path relative to root
+func relTo(root, file string) string {
+	if root == "." {
+		return file
+	}
+	return strings.TrimPrefix(file, strings.TrimSuffix(root, "/")+"/")
+}
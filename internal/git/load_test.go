@@ -0,0 +1,94 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+// chdirRepo creates a repository with a commit adding old.yaml and a second
+// commit replacing it with new.yaml, and changes into it
+func chdirRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("old.yaml", "kind: ConfigMap\nmetadata:\n  name: old\n")
+	git("add", "old.yaml")
+	git("commit", "-q", "-m", "base")
+	git("tag", "base")
+	git("rm", "-q", "old.yaml")
+	write("new.yaml", "kind: ConfigMap\nmetadata:\n  name: new\n")
+	git("add", "new.yaml")
+	git("commit", "-q", "-m", "head")
+}
+
+func TestParsePathMissing(t *testing.T) {
+	chdirRepo(t)
+
+	tests := []struct {
+		rev, path string
+		docs      int
+		missing   bool
+	}{
+		{"base", "old.yaml", 1, false},
+		{"base", "new.yaml", 0, true},
+		{"HEAD", "old.yaml", 0, true},
+		{"HEAD", "*.yaml", 1, false},
+		{"HEAD", "manifests/**/*.yaml", 0, true},
+	}
+	for _, tt := range tests {
+		docs, err := ParsePath(tt.rev, tt.path, parser.FormatAuto)
+		var notExist *NotExistError
+		if missing := errors.As(err, &notExist); missing != tt.missing {
+			t.Errorf("ParsePath(%s, %s) error = %v, want missing %v", tt.rev, tt.path, err, tt.missing)
+			continue
+		}
+		if !tt.missing && err != nil {
+			t.Errorf("ParsePath(%s, %s) error = %v", tt.rev, tt.path, err)
+		}
+		if len(docs) != tt.docs {
+			t.Errorf("ParsePath(%s, %s) = %d documents, want %d", tt.rev, tt.path, len(docs), tt.docs)
+		}
+	}
+}
+
+func TestParsePathUnknownRevision(t *testing.T) {
+	chdirRepo(t)
+
+	_, err := ParsePath("no-such-rev", "old.yaml", parser.FormatAuto)
+	var notExist *NotExistError
+	if err == nil || errors.As(err, &notExist) {
+		t.Errorf("ParsePath() error = %v, want an unknown revision error", err)
+	}
+}
@@ -0,0 +1,167 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the REST API endpoint of github.com. GitHub Enterprise
+// Server uses https://HOSTNAME/api/v3.
+const DefaultBaseURL = "https://api.github.com"
+
+// Client talks to the GitHub REST API
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// APIError is returned when the GitHub API answers with a non-2xx status
+type APIError struct {
+	Method           string
+	URL              string
+	StatusCode       int
+	Message          string
+	DocumentationURL string
+	Errors           []APIErrorDetail
+}
+
+// APIErrorDetail is a single validation error reported by the API
+type APIErrorDetail struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// Error formats the status code, request and API message
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("GitHub API %s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, detail := range e.Errors {
+		switch {
+		case detail.Message != "":
+			msg += fmt.Sprintf(" (%s)", detail.Message)
+		case detail.Code != "":
+			msg += fmt.Sprintf(" (%s %s %s)", detail.Resource, detail.Field, detail.Code)
+		}
+	}
+	return msg
+}
+
+// NewClient creates a client authenticated with token. An empty baseURL
+// uses DefaultBaseURL.
+func NewClient(token, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PostComment posts a comment to a GitHub PR
+func (c *Client) PostComment(repo string, prNumber int, body string) error {
+	path, err := issuePath(repo, prNumber, "comments")
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodPost, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Posted GitHub comment\n")
+	return nil
+}
+
+// do sends a JSON request to a path of the REST API and decodes the JSON
+// response into out when out is not nil
+func (c *Client) do(method, path string, in, out interface{}) error {
+	return c.doURL(method, c.baseURL+path, in, out)
+}
+
+// doURL is like do but takes a full URL
+func (c *Client) doURL(method, reqURL string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, reqURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "yamldiff")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{Method: method, URL: reqURL, StatusCode: resp.StatusCode}
+		var payload struct {
+			Message          string           `json:"message"`
+			DocumentationURL string           `json:"documentation_url"`
+			Errors           []APIErrorDetail `json:"errors"`
+		}
+		if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &payload) == nil {
+			apiErr.Message = payload.Message
+			apiErr.DocumentationURL = payload.DocumentationURL
+			apiErr.Errors = payload.Errors
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s %s: %w", method, reqURL, err)
+	}
+	return nil
+}
+
+// issuePath builds /repos/{owner}/{repo}/issues/{number}/{suffix}. Pull
+// requests share comments and labels with their issue.
+func issuePath(repo string, prNumber int, suffix string) (string, error) {
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/repos/%s/%s/issues/%d/%s",
+		url.PathEscape(owner), url.PathEscape(name), prNumber, suffix), nil
+}
+
+func splitRepo(repo string) (string, string, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid repository %q (expected owner/repo)", repo)
+	}
+	return owner, name, nil
+}
@@ -0,0 +1,291 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// request is what the test server saw of a request
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+// newTestClient starts a server answering with handler and returns a
+// client for it with the requests it received
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *[]request) {
+	t.Helper()
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.RawQuery,
+			Header: r.Header,
+			Body:   string(body),
+		})
+		if handler != nil {
+			handler(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewClient("secret", server.URL), &requests
+}
+
+func TestPostComment(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 1}`)
+	})
+
+	if err := client.PostComment("owner/repo", 7, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/repos/owner/repo/issues/7/comments" {
+		t.Errorf("request = %s %s", req.Method, req.Path)
+	}
+	if req.Body != `{"body":"hello"}` {
+		t.Errorf("body = %s", req.Body)
+	}
+	for header, want := range map[string]string{
+		"Authorization":        "Bearer secret",
+		"Accept":               "application/vnd.github+json",
+		"Content-Type":         "application/json",
+		"X-Github-Api-Version": "2022-11-28",
+	} {
+		if got := req.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestNoAuthorizationWithoutToken(t *testing.T) {
+	client, requests := newTestClient(t, nil)
+	client.token = ""
+
+	if err := client.PostComment("owner/repo", 7, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*requests)[0].Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	client, requests := newTestClient(t, nil)
+
+	if err := client.AddLabels("owner/repo", 7, []string{"a", "", "b c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AddLabels("owner/repo", 7, []string{""}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1 (empty labels are not sent)", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/repos/owner/repo/issues/7/labels" {
+		t.Errorf("request = %s %s", req.Method, req.Path)
+	}
+	if req.Body != `{"labels":["a","b c"]}` {
+		t.Errorf("body = %s", req.Body)
+	}
+}
+
+func TestRemoveLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"removed", http.StatusOK, false},
+		{"not on the PR", http.StatusNotFound, false},
+		{"server error", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `[]`)
+			})
+
+			err := client.RemoveLabel("owner/repo", 7, "needs review/yaml")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveLabel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			req := (*requests)[0]
+			if req.Method != http.MethodDelete || req.Path != "/repos/owner/repo/issues/7/labels/needs%20review%2Fyaml" {
+				t.Errorf("request = %s %s", req.Method, req.Path)
+			}
+		})
+	}
+}
+
+// paginate answers list requests with total items split into pages of
+// per_page, each built by item
+func paginate(total int, item func(i int) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		batch := []interface{}{}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			batch = append(batch, item(i))
+		}
+		_ = json.NewEncoder(w).Encode(batch)
+	}
+}
+
+func TestListLabelsPagination(t *testing.T) {
+	client, requests := newTestClient(t, paginate(150, func(i int) interface{} {
+		return map[string]string{"name": fmt.Sprintf("label-%d", i)}
+	}))
+
+	labels, err := client.ListLabels("owner/repo", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 150 || labels[0] != "label-0" || labels[149] != "label-149" {
+		t.Errorf("got %d labels (%q ... %q), want 150", len(labels), labels[0], labels[len(labels)-1])
+	}
+	var queries []string
+	for _, req := range *requests {
+		queries = append(queries, req.Query)
+	}
+	if want := []string{"per_page=100&page=1", "per_page=100&page=2"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+}
+
+func TestListCommentsPagination(t *testing.T) {
+	// A full last page needs one more request to find the end
+	client, requests := newTestClient(t, paginate(200, func(i int) interface{} {
+		return IssueComment{ID: int64(i + 1), NodeID: fmt.Sprintf("IC_%d", i+1), Body: "body"}
+	}))
+
+	comments, err := client.ListComments("owner/repo", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 200 || comments[199].ID != 200 || comments[199].NodeID != "IC_200" {
+		t.Errorf("got %d comments, want 200 in order", len(comments))
+	}
+	if len(*requests) != 3 {
+		t.Errorf("got %d requests, want 3", len(*requests))
+	}
+	if path := (*requests)[0].Path; path != "/repos/owner/repo/issues/7/comments" {
+		t.Errorf("path = %s", path)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{
+  "message": "Validation Failed",
+  "documentation_url": "https://docs.github.com/rest",
+  "errors": [
+    {"resource": "Label", "field": "name", "code": "invalid"},
+    {"message": "name is too long"}
+  ]
+}`)
+	})
+
+	err := client.AddLabels("owner/repo", 7, []string{"x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Method != http.MethodPost ||
+		apiErr.Message != "Validation Failed" || apiErr.DocumentationURL != "https://docs.github.com/rest" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	wantDetails := []APIErrorDetail{
+		{Resource: "Label", Field: "name", Code: "invalid"},
+		{Message: "name is too long"},
+	}
+	if !reflect.DeepEqual(apiErr.Errors, wantDetails) {
+		t.Errorf("Errors = %+v, want %+v", apiErr.Errors, wantDetails)
+	}
+	for _, part := range []string{"422 Unprocessable Entity", "Validation Failed", "(Label name invalid)", "(name is too long)"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not contain %q", err, part)
+		}
+	}
+}
+
+func TestAPIErrorWithoutJSON(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+
+	err := client.PostComment("owner/repo", 7, "hello")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "" {
+		t.Errorf("error = %v, want a 502 APIError without message", err)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"", "https://api.github.com/graphql"},
+		{"https://api.github.com/", "https://api.github.com/graphql"},
+		{"https://ghe.example.com/api/v3", "https://ghe.example.com/api/graphql"},
+		{"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/graphql"},
+	}
+	for _, tt := range tests {
+		if got := NewClient("", tt.baseURL).graphQLURL(); got != tt.want {
+			t.Errorf("graphQLURL() for %q = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestMinimizeCommentOnEnterpriseServer(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {}}`)
+	})
+	client.baseURL += "/api/v3"
+
+	if err := client.MinimizeComment("IC_1"); err != nil {
+		t.Fatal(err)
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/api/graphql" {
+		t.Errorf("request = %s %s, want POST /api/graphql", req.Method, req.Path)
+	}
+	var payload struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if err := json.Unmarshal([]byte(req.Body), &payload); err != nil || payload.Variables["id"] != "IC_1" {
+		t.Errorf("body = %s", req.Body)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors": [{"message": "not allowed"}, {"message": "try again"}]}`)
+	})
+
+	err := client.MinimizeComment("IC_1")
+	if err == nil || !strings.Contains(err.Error(), "GraphQL error: not allowed; try again") {
+		t.Errorf("error = %v", err)
+	}
+}
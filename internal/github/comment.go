@@ -0,0 +1,240 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// CommentMode decides what happens to comments from previous runs
+type CommentMode string
+
+const (
+	// CommentCreate always posts a new comment
+	CommentCreate CommentMode = "create"
+	// CommentUpdate edits the latest previous comment in place, or posts a
+	// new one if there is none
+	CommentUpdate CommentMode = "update"
+	// CommentMinimize posts a new comment and hides previous ones as outdated
+	CommentMinimize CommentMode = "minimize"
+	// CommentDelete posts a new comment and deletes previous ones
+	CommentDelete CommentMode = "delete"
+)
+
+// ParseCommentMode validates a comment mode. An empty string means
+// CommentCreate.
+func ParseCommentMode(s string) (CommentMode, error) {
+	switch mode := CommentMode(s); mode {
+	case "":
+		return CommentCreate, nil
+	case CommentCreate, CommentUpdate, CommentMinimize, CommentDelete:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid comment mode %q (expected create, update, minimize or delete)", s)
+	}
+}
+
+// IssueComment is a comment on an issue or pull request
+type IssueComment struct {
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id"`
+	Body   string `json:"body"`
+	User   User   `json:"user"`
+}
+
+// User is a GitHub account
+type User struct {
+	Login string `json:"login"`
+}
+
+// ActionsBotLogin is the author of comments posted with the GITHUB_TOKEN
+// of a GitHub Actions workflow
+const ActionsBotLogin = "github-actions[bot]"
+
+// Marker returns the hidden HTML comment that tags yamldiff comments for a
+// target, so several yamldiff jobs on one PR keep separate comments
+func Marker(target string) string {
+	return fmt.Sprintf("<!-- yamldiff:target=%s -->", url.QueryEscape(target))
+}
+
+// PublishComment posts body tagged with the marker of target and handles
+// comments of previous runs for the same target according to mode. Only
+// comments written by the authenticated user count as previous runs, so a
+// marker copied into someone else's comment is ignored.
+func (c *Client) PublishComment(repo string, prNumber int, body string, mode CommentMode, target string) error {
+	marker := Marker(target)
+	body = strings.TrimRight(body, "\n") + "\n\n" + marker + "\n"
+
+	if mode == CommentCreate {
+		return c.PostComment(repo, prNumber, body)
+	}
+
+	login, err := c.AuthenticatedLogin()
+	if err != nil {
+		return err
+	}
+	comments, err := c.ListComments(repo, prNumber)
+	if err != nil {
+		return err
+	}
+	var previous []IssueComment
+	for _, comment := range comments {
+		if comment.User.Login == login && strings.Contains(comment.Body, marker) {
+			previous = append(previous, comment)
+		}
+	}
+
+	if mode == CommentUpdate {
+		if len(previous) == 0 {
+			return c.PostComment(repo, prNumber, body)
+		}
+		// Comments are listed oldest first
+		return c.UpdateComment(repo, previous[len(previous)-1].ID, body)
+	}
+
+	if err := c.PostComment(repo, prNumber, body); err != nil {
+		return err
+	}
+	for _, comment := range previous {
+		switch mode {
+		case CommentMinimize:
+			err = c.MinimizeComment(comment.NodeID)
+		case CommentDelete:
+			err = c.DeleteComment(repo, comment.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthenticatedLogin returns the login of the user the token belongs to.
+// The GITHUB_TOKEN of a GitHub Actions workflow can't read /user; inside
+// Actions, a 403 from it is taken to mean comments are posted as
+// ActionsBotLogin.
+func (c *Client) AuthenticatedLogin() (string, error) {
+	var user User
+	if err := c.do(http.MethodGet, "/user", nil, &user); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden && os.Getenv("GITHUB_ACTIONS") == "true" {
+			return ActionsBotLogin, nil
+		}
+		return "", fmt.Errorf("failed to get the authenticated user: %w", err)
+	}
+	if user.Login == "" {
+		return "", fmt.Errorf("failed to get the authenticated user: empty login")
+	}
+	return user.Login, nil
+}
+
+// ListComments returns all comments of a GitHub PR, oldest first
+func (c *Client) ListComments(repo string, prNumber int) ([]IssueComment, error) {
+	path, err := issuePath(repo, prNumber, "comments")
+	if err != nil {
+		return nil, err
+	}
+
+	const perPage = 100
+	var comments []IssueComment
+	for page := 1; ; page++ {
+		var batch []IssueComment
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", path, perPage, page), nil, &batch); err != nil {
+			return nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+		comments = append(comments, batch...)
+		if len(batch) < perPage {
+			return comments, nil
+		}
+	}
+}
+
+// UpdateComment replaces the body of a comment
+func (c *Client) UpdateComment(repo string, commentID int64, body string) error {
+	path, err := commentPath(repo, commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodPatch, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Updated GitHub comment\n")
+	return nil
+}
+
+// DeleteComment deletes a comment
+func (c *Client) DeleteComment(repo string, commentID int64) error {
+	path, err := commentPath(repo, commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Deleted previous GitHub comment\n")
+	return nil
+}
+
+// MinimizeComment hides a comment as outdated. Minimizing is only
+// available through the GraphQL API.
+func (c *Client) MinimizeComment(nodeID string) error {
+	query := `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) {
+    minimizedComment { isMinimized }
+  }
+}`
+	if err := c.graphQL(query, map[string]interface{}{"id": nodeID}); err != nil {
+		return fmt.Errorf("failed to minimize comment: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Minimized previous GitHub comment\n")
+	return nil
+}
+
+// graphQL runs a GraphQL query against the endpoint that belongs to the
+// REST base URL
+func (c *Client) graphQL(query string, variables map[string]interface{}) error {
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	in := map[string]interface{}{"query": query, "variables": variables}
+	if err := c.doURL(http.MethodPost, c.graphQLURL(), in, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// graphQLURL maps the REST base URL to the GraphQL endpoint:
+// https://api.github.com → https://api.github.com/graphql and
+// https://HOST/api/v3 → https://HOST/api/graphql
+func (c *Client) graphQLURL() string {
+	if strings.HasSuffix(c.baseURL, "/api/v3") {
+		return strings.TrimSuffix(c.baseURL, "/v3") + "/graphql"
+	}
+	return c.baseURL + "/graphql"
+}
+
+func commentPath(repo string, commentID int64) (string, error) {
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/repos/%s/%s/issues/comments/%d",
+		url.PathEscape(owner), url.PathEscape(name), commentID), nil
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// commentServer serves /user as login, or a 403 when login is empty, and
+// the given comments of PR 7
+func commentServer(login string, comments []IssueComment) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user" && login == "":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+		case r.URL.Path == "/user":
+			fmt.Fprintf(w, `{"login": %q}`, login)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/issues/7/comments":
+			_ = json.NewEncoder(w).Encode(comments)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}
+}
+
+func TestPublishCommentIgnoresOtherAuthors(t *testing.T) {
+	marker := Marker("prod")
+	comments := []IssueComment{
+		{ID: 1, NodeID: "IC_1", Body: "old run\n\n" + marker, User: User{Login: "ci-bot"}},
+		{ID: 2, NodeID: "IC_2", Body: "spoofed\n\n" + marker, User: User{Login: "mallory"}},
+		{ID: 3, NodeID: "IC_3", Body: "unrelated", User: User{Login: "ci-bot"}},
+	}
+
+	tests := []struct {
+		mode CommentMode
+		want []string
+	}{
+		{CommentUpdate, []string{
+			"GET /user",
+			"GET /repos/owner/repo/issues/7/comments",
+			"PATCH /repos/owner/repo/issues/comments/1",
+		}},
+		{CommentDelete, []string{
+			"GET /user",
+			"GET /repos/owner/repo/issues/7/comments",
+			"POST /repos/owner/repo/issues/7/comments",
+			"DELETE /repos/owner/repo/issues/comments/1",
+		}},
+		{CommentCreate, []string{
+			"POST /repos/owner/repo/issues/7/comments",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			client, requests := newTestClient(t, commentServer("ci-bot", comments))
+
+			if err := client.PublishComment("owner/repo", 7, "new run", tt.mode, "prod"); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, req := range *requests {
+				got = append(got, req.Method+" "+req.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishCommentSpoofedOnly(t *testing.T) {
+	comments := []IssueComment{
+		{ID: 2, Body: "spoofed\n\n" + Marker("prod"), User: User{Login: "mallory"}},
+	}
+	client, requests := newTestClient(t, commentServer("ci-bot", comments))
+
+	if err := client.PublishComment("owner/repo", 7, "new run", CommentUpdate, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	last := (*requests)[len(*requests)-1]
+	if last.Method != http.MethodPost || last.Path != "/repos/owner/repo/issues/7/comments" {
+		t.Errorf("last request = %s %s, want a new comment", last.Method, last.Path)
+	}
+}
+
+func TestAuthenticatedLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		login   string
+		actions string
+		want    string
+		wantErr bool
+	}{
+		{"user token", "ci-bot", "", "ci-bot", false},
+		{"actions token", "", "true", ActionsBotLogin, false},
+		{"forbidden outside actions", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", tt.actions)
+			client, _ := newTestClient(t, commentServer(tt.login, nil))
+
+			got, err := client.AuthenticatedLogin()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthenticatedLogin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AuthenticatedLogin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,6 @@ package github
 import (
 	"bytes"
 	"fmt"
-	"os"
-	"os/exec"
 	"sort"
 	"text/template"
 
@@ -19,64 +17,19 @@ type TemplateData struct {
 	Added        int
 	Deleted      int
 	Modified     int
+	Moved        int
+	Renamed      int
+	Reformatted  int
 	AddedList    []string
 	DeletedList  []string
 	ModifiedList []string
-	Link         string
-	Vars         map[string]interface{}
-}
-
-// PostComment posts a comment to a GitHub PR
-func PostComment(repo string, prNumber int, body string) error {
-	cmd := exec.Command("gh", "pr", "comment", fmt.Sprintf("%d", prNumber),
-		"--repo", repo,
-		"--body", body)
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to post comment: %w\nOutput: %s", err, string(output))
-	}
-
-	fmt.Fprintf(os.Stderr, "✓ Posted GitHub comment\n")
-	return nil
-}
-
-// AddLabel adds a label to a GitHub PR
-func AddLabel(repo string, prNumber int, label string) error {
-	if label == "" {
-		return nil
-	}
-
-	cmd := exec.Command("gh", "pr", "edit", fmt.Sprintf("%d", prNumber),
-		"--repo", repo,
-		"--add-label", label)
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to add label: %w\nOutput: %s", err, string(output))
-	}
-
-	fmt.Fprintf(os.Stderr, "✓ Applied GitHub label: %s\n", label)
-	return nil
-}
-
-// AddLabels adds multiple labels to a GitHub PR
-func AddLabels(repo string, prNumber int, labels []string) error {
-	if len(labels) == 0 {
-		return nil
-	}
-
-	for _, label := range labels {
-		if label == "" {
-			continue
-		}
-
-		if err := AddLabel(repo, prNumber, label); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	MovedList    []string
+	RenamedList  []string
+	// ReformattedList names documents whose formatting changed, with
+	// --formatting-sensitive
+	ReformattedList []string
+	Link            string
+	Vars            map[string]interface{}
 }
 
 // RenderTemplate renders a template with the given data
@@ -101,6 +54,12 @@ func PrepareTemplateData(result *diff.Result, details string, link string, vars
 	modified := len(result.Modified)
 
 	summary := fmt.Sprintf("Plan: %d to add, %d to delete, %d to modify", added, deleted, modified)
+	if len(result.Renamed) > 0 {
+		summary += fmt.Sprintf(", %d to rename", len(result.Renamed))
+	}
+	if len(result.Reformatted) > 0 {
+		summary += fmt.Sprintf(", %d to reformat", len(result.Reformatted))
+	}
 
 	// Extract and sort keys
 	addedList := make([]string, 0, len(result.Added))
@@ -121,17 +80,46 @@ func PrepareTemplateData(result *diff.Result, details string, link string, vars
 	}
 	sort.Strings(modifiedList)
 
+	movedList := make([]string, 0, len(result.Moved))
+	for k := range result.Moved {
+		movedList = append(movedList, k)
+	}
+	sort.Strings(movedList)
+
+	// Renames are listed as "old → new", sorted by the new key
+	renamedKeys := make([]string, 0, len(result.Renamed))
+	for k := range result.Renamed {
+		renamedKeys = append(renamedKeys, k)
+	}
+	sort.Strings(renamedKeys)
+	renamedList := make([]string, 0, len(renamedKeys))
+	for _, k := range renamedKeys {
+		renamedList = append(renamedList, result.Renamed[k].OldKey+" → "+k)
+	}
+
+	reformattedList := make([]string, 0, len(result.Reformatted))
+	for k := range result.Reformatted {
+		reformattedList = append(reformattedList, k)
+	}
+	sort.Strings(reformattedList)
+
 	return TemplateData{
-		Summary:      summary,
-		Details:      details,
-		HasChanges:   result.HasDifferences(),
-		Added:        added,
-		Deleted:      deleted,
-		Modified:     modified,
-		AddedList:    addedList,
-		DeletedList:  deletedList,
-		ModifiedList: modifiedList,
-		Link:         link,
-		Vars:         vars,
+		Summary:         summary,
+		Details:         details,
+		HasChanges:      result.HasContentChanges(),
+		Added:           added,
+		Deleted:         deleted,
+		Modified:        modified,
+		Moved:           len(result.Moved),
+		Renamed:         len(result.Renamed),
+		Reformatted:     len(result.Reformatted),
+		AddedList:       addedList,
+		DeletedList:     deletedList,
+		ModifiedList:    modifiedList,
+		MovedList:       movedList,
+		RenamedList:     renamedList,
+		ReformattedList: reformattedList,
+		Link:            link,
+		Vars:            vars,
 	}
 }
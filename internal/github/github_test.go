@@ -0,0 +1,27 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/tyuhara/yamldiff/internal/diff"
+	"github.com/tyuhara/yamldiff/internal/parser"
+)
+
+func TestPrepareTemplateDataHasChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *diff.Result
+		want   bool
+	}{
+		{"no changes", &diff.Result{}, false},
+		{"added", &diff.Result{Added: map[string]parser.Document{"ConfigMap/app": {}}}, true},
+		{"reformatted only", &diff.Result{Reformatted: map[string]diff.ModifiedDoc{"ConfigMap/app": {}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrepareTemplateData(tt.result, "", "", nil).HasChanges; got != tt.want {
+				t.Errorf("HasChanges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
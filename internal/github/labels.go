@@ -0,0 +1,126 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// AddLabel adds a label to a GitHub PR
+func (c *Client) AddLabel(repo string, prNumber int, label string) error {
+	if label == "" {
+		return nil
+	}
+	return c.AddLabels(repo, prNumber, []string{label})
+}
+
+// AddLabels adds multiple labels to a GitHub PR
+func (c *Client) AddLabels(repo string, prNumber int, labels []string) error {
+	var nonEmpty []string
+	for _, label := range labels {
+		if label != "" {
+			nonEmpty = append(nonEmpty, label)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return nil
+	}
+
+	path, err := issuePath(repo, prNumber, "labels")
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodPost, path, map[string][]string{"labels": nonEmpty}, nil); err != nil {
+		return fmt.Errorf("failed to add labels: %w", err)
+	}
+
+	for _, label := range nonEmpty {
+		fmt.Fprintf(os.Stderr, "✓ Applied GitHub label: %s\n", label)
+	}
+	return nil
+}
+
+// ListLabels returns the names of the labels on a GitHub PR
+func (c *Client) ListLabels(repo string, prNumber int) ([]string, error) {
+	path, err := issuePath(repo, prNumber, "labels")
+	if err != nil {
+		return nil, err
+	}
+
+	const perPage = 100
+	var names []string
+	for page := 1; ; page++ {
+		var batch []struct {
+			Name string `json:"name"`
+		}
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", path, perPage, page), nil, &batch); err != nil {
+			return nil, fmt.Errorf("failed to list labels: %w", err)
+		}
+		for _, label := range batch {
+			names = append(names, label.Name)
+		}
+		if len(batch) < perPage {
+			return names, nil
+		}
+	}
+}
+
+// RemoveLabel removes a label from a GitHub PR. Removing a label the PR
+// does not have is not an error.
+func (c *Client) RemoveLabel(repo string, prNumber int, label string) error {
+	path, err := issuePath(repo, prNumber, "labels/"+url.PathEscape(label))
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(http.MethodDelete, path, nil, nil); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to remove label: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Removed GitHub label: %s\n", label)
+	return nil
+}
+
+// ReconcileLabels makes the managed labels on a GitHub PR match desired:
+// managed labels that no longer apply are removed and missing desired
+// labels are added. Labels outside the managed set are left alone.
+func (c *Client) ReconcileLabels(repo string, prNumber int, desired, managed []string) error {
+	current, err := c.ListLabels(repo, prNumber)
+	if err != nil {
+		return err
+	}
+
+	has := make(map[string]bool, len(current))
+	for _, label := range current {
+		has[label] = true
+	}
+	want := make(map[string]bool, len(desired))
+	for _, label := range desired {
+		want[label] = true
+	}
+
+	for _, label := range managed {
+		if label != "" && has[label] && !want[label] {
+			if err := c.RemoveLabel(repo, prNumber, label); err != nil {
+				return err
+			}
+			has[label] = false
+		}
+	}
+
+	var missing []string
+	for _, label := range desired {
+		if label != "" && !has[label] {
+			missing = append(missing, label)
+			has[label] = true
+		}
+	}
+	return c.AddLabels(repo, prNumber, missing)
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// labelServer serves current as the labels of PR 7 and answers deleting a
+// label with 404 when it is in gone
+func labelServer(current []string, gone ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			var labels []map[string]string
+			for _, name := range current {
+				labels = append(labels, map[string]string{"name": name})
+			}
+			_ = json.NewEncoder(w).Encode(labels)
+		case http.MethodDelete:
+			for _, name := range gone {
+				if strings.HasSuffix(r.URL.Path, "/labels/"+name) {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			fmt.Fprint(w, `[]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}
+}
+
+func TestReconcileLabels(t *testing.T) {
+	const labels = "/repos/owner/repo/issues/7/labels"
+	tests := []struct {
+		name    string
+		current []string
+		gone    []string
+		desired []string
+		managed []string
+		want    []string
+	}{
+		{
+			name:    "stale managed label",
+			current: []string{"yaml/modified", "yaml/added"},
+			desired: []string{"yaml/added"},
+			managed: []string{"yaml/added", "yaml/modified", "yaml/no-changes"},
+			want:    []string{"DELETE " + labels + "/yaml%2Fmodified"},
+		},
+		{
+			name:    "unmanaged labels",
+			current: []string{"bug", "yaml/added"},
+			desired: []string{"yaml/no-changes"},
+			managed: []string{"yaml/added", "yaml/no-changes"},
+			want: []string{
+				"DELETE " + labels + "/yaml%2Fadded",
+				`POST ` + labels + ` {"labels":["yaml/no-changes"]}`,
+			},
+		},
+		{
+			name:    "missing labels",
+			current: []string{"yaml/added"},
+			desired: []string{"yaml/added", "yaml/deleted", "", "yaml/modified"},
+			managed: []string{"yaml/added", "yaml/deleted", "yaml/modified"},
+			want:    []string{`POST ` + labels + ` {"labels":["yaml/deleted","yaml/modified"]}`},
+		},
+		{
+			name:    "already removed",
+			current: []string{"yaml/modified"},
+			gone:    []string{"yaml/modified"},
+			managed: []string{"yaml/modified"},
+			want:    []string{"DELETE " + labels + "/yaml%2Fmodified"},
+		},
+		{
+			name:    "duplicates",
+			current: []string{"changed"},
+			desired: []string{"yaml", "yaml"},
+			managed: []string{"changed", "changed", "yaml", "yaml"},
+			want: []string{
+				"DELETE " + labels + "/changed",
+				`POST ` + labels + ` {"labels":["yaml"]}`,
+			},
+		},
+		{
+			name:    "up to date",
+			current: []string{"bug", "yaml/added"},
+			desired: []string{"yaml/added"},
+			managed: []string{"yaml/added", "yaml/deleted"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newTestClient(t, labelServer(tt.current, tt.gone...))
+
+			if err := client.ReconcileLabels("owner/repo", 7, tt.desired, tt.managed); err != nil {
+				t.Fatal(err)
+			}
+			if (*requests)[0].Method != http.MethodGet {
+				t.Errorf("first request = %s, want the current labels", (*requests)[0].Method)
+			}
+			var got []string
+			for _, req := range (*requests)[1:] {
+				got = append(got, strings.TrimSpace(req.Method+" "+req.Path+" "+req.Body))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
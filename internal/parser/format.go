@@ -0,0 +1,306 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the syntax of an input
+type Format string
+
+const (
+	// FormatAuto picks the format from the file extension, falling back to
+	// YAML
+	FormatAuto Format = "auto"
+	// FormatYAML is YAML, with "---" separating documents
+	FormatYAML Format = "yaml"
+	// FormatJSON is a single JSON value per file
+	FormatJSON Format = "json"
+	// FormatJSONL is JSON Lines: one JSON value, and so one document, per line
+	FormatJSONL Format = "jsonl"
+	// FormatTOML is a single TOML document per file
+	FormatTOML Format = "toml"
+)
+
+var formatExtensions = map[Format][]string{
+	FormatYAML:  {".yaml", ".yml"},
+	FormatJSON:  {".json"},
+	FormatJSONL: {".jsonl", ".ndjson"},
+	FormatTOML:  {".toml"},
+}
+
+// ParseFormat validates a format name. An empty string means FormatAuto.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return FormatAuto, nil
+	case FormatAuto, FormatYAML, FormatJSON, FormatJSONL, FormatTOML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid input format %q (expected auto, yaml, json, jsonl or toml)", s)
+	}
+}
+
+// DetectFormat returns the format of a file from its extension. Unknown
+// extensions are read as YAML.
+func DetectFormat(filename string) Format {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for format, exts := range formatExtensions {
+		for _, e := range exts {
+			if ext == e {
+				return format
+			}
+		}
+	}
+	return FormatYAML
+}
+
+// Matches reports whether a file found in a directory should be read as
+// this format. FormatAuto only picks YAML files.
+func (f Format) Matches(path string) bool {
+	if f == FormatAuto {
+		f = FormatYAML
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range formatExtensions[f] {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFile parses every document of a file in the given format
+func ParseFile(filename string, format Format) ([]Document, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseReader(f, filename, format)
+}
+
+// ParseBytes parses every document of data in the given format. filename is
+// used to detect the format with FormatAuto and to label the documents.
+func ParseBytes(data []byte, filename string, format Format) ([]Document, error) {
+	return ParseReader(bytes.NewReader(data), filename, format)
+}
+
+// ParseReader parses every document read from r in the given format.
+// filename is used to detect the format with FormatAuto and to label the
+// documents.
+func ParseReader(r io.Reader, filename string, format Format) ([]Document, error) {
+	if format == FormatAuto {
+		format = DetectFormat(filename)
+	}
+
+	switch format {
+	case FormatJSON:
+		return parseJSON(r, filename)
+	case FormatJSONL:
+		return parseJSONLines(r, filename)
+	case FormatTOML:
+		return parseTOML(r, filename)
+	default:
+		return ParseMultiDocYAMLReader(r, filename)
+	}
+}
+
+// parseJSON reads a single JSON value. JSON is parsed as YAML, of which it
+// is a subset, to keep line and column information.
+func parseJSON(r io.Reader, filename string) ([]Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	node, err := jsonNode(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := newDocument(node, filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []Document{doc}, nil
+}
+
+// parseJSONLines reads one document per non-blank line
+func parseJSONLines(r io.Reader, filename string) ([]Document, error) {
+	var docs []Document
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		node, err := jsonNode(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		shiftLines(node, line-1)
+
+		doc, err := newDocument(node, filename, len(docs))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// parseTOML reads a single TOML document. The TOML decoder doesn't keep
+// positions, so changes in TOML documents carry none.
+func parseTOML(r io.Reader, filename string) ([]Document, error) {
+	var content map[string]interface{}
+	if _, err := toml.NewDecoder(r).Decode(&content); err != nil {
+		return nil, err
+	}
+
+	// Round-trip through a node so values get the same Go types as YAML
+	var node yaml.Node
+	if err := node.Encode(content); err != nil {
+		return nil, err
+	}
+	doc, err := newDocument(&node, filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []Document{doc}, nil
+}
+
+// jsonNode validates a JSON value and parses it into a node. An object
+// key given twice keeps its last value, as with encoding/json. The few
+// valid JSON texts YAML rejects, such as keys longer than 1024 characters
+// or a leading tab, are decoded with encoding/json instead and carry no
+// positions.
+func jsonNode(data []byte) (*yaml.Node, error) {
+	if !json.Valid(data) {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(unescapeSolidus(data), &node); err != nil {
+		return decodeJSON(data)
+	}
+	dropDuplicateKeys(&node)
+	return &node, nil
+}
+
+// decodeJSON parses a JSON value with encoding/json and round-trips it
+// through a node, like parseTOML
+func decodeJSON(data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var content interface{}
+	if err := decoder.Decode(&content); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(jsonNumbers(content)); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// jsonNumbers turns json.Number values into integers where they fit, so
+// they decode to the same types as numbers parsed as YAML
+func jsonNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = jsonNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = jsonNumbers(item)
+		}
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	}
+	return v
+}
+
+// dropDuplicateKeys removes all but the last entry of keys that appear
+// more than once in a mapping, which YAML rejects and JSON allows
+func dropDuplicateKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		last := make(map[string]int, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			last[node.Content[i].Value] = i
+		}
+		if len(last) < len(node.Content)/2 {
+			content := node.Content[:0]
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if last[node.Content[i].Value] == i {
+					content = append(content, node.Content[i], node.Content[i+1])
+				}
+			}
+			node.Content = content
+		}
+	}
+	for _, child := range node.Content {
+		dropDuplicateKeys(child)
+	}
+}
+
+// unescapeSolidus replaces the JSON escape \/ inside strings, which YAML
+// doesn't know, with a plain /
+func unescapeSolidus(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`\/`)) {
+		return data
+	}
+
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' && i+1 < len(data) {
+			if data[i+1] != '/' {
+				out = append(out, data[i])
+			}
+			i++
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+// shiftLines moves the positions of a node tree down by offset lines.
+// Nodes without a position keep none.
+func shiftLines(node *yaml.Node, offset int) {
+	if node == nil || offset == 0 {
+		return
+	}
+	if node.Line > 0 {
+		node.Line += offset
+	}
+	for _, child := range node.Content {
+		shiftLines(child, offset)
+	}
+}
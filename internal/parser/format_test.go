@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	longKey := strings.Repeat("k", 1100)
+	tests := []struct {
+		name     string
+		input    string
+		want     interface{}
+		position bool
+	}{
+		{
+			name:     "plain",
+			input:    `{"a": 1, "b": [true, null], "c": "x\/y"}`,
+			want:     map[string]interface{}{"a": 1, "b": []interface{}{true, nil}, "c": "x/y"},
+			position: true,
+		},
+		{
+			name:     "duplicate keys keep the last value",
+			input:    `{"a": 1, "b": {"c": 1, "c": 2}, "a": 3}`,
+			want:     map[string]interface{}{"a": 3, "b": map[string]interface{}{"c": 2}},
+			position: true,
+		},
+		{
+			name:  "key too long for YAML",
+			input: `{"` + longKey + `": 1, "big": 12345678901234567890, "f": 1.5}`,
+			want:  map[string]interface{}{longKey: 1, "big": uint64(12345678901234567890), "f": 1.5},
+		},
+		{
+			name:  "leading tab",
+			input: "\t{\"a\": 1}",
+			want:  map[string]interface{}{"a": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParseBytes([]byte(tt.input), "test.json", FormatJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) != 1 {
+				t.Fatalf("got %d documents, want 1", len(docs))
+			}
+			if !reflect.DeepEqual(docs[0].Content, tt.want) {
+				t.Errorf("content = %#v, want %#v", docs[0].Content, tt.want)
+			}
+			if hasPosition := docs[0].Node.Line > 0; hasPosition != tt.position {
+				t.Errorf("position = %v, want %v", hasPosition, tt.position)
+			}
+		})
+	}
+}
+
+func TestParseJSONInvalid(t *testing.T) {
+	if _, err := ParseBytes([]byte(`{"a": 1,}`), "test.json", FormatJSON); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("error = %v, want invalid JSON", err)
+	}
+}
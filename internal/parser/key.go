@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// KubernetesKey is the name of the Kubernetes identifier preset. It
+// identifies documents by kind, namespace and name, e.g.
+// "Deployment/default/web", omitting the namespace for cluster-scoped
+// objects ("ClusterRole/admin") and the kind when it is not set.
+const KubernetesKey = "kubernetes"
+
+// Identifier extracts the identifier of a document
+type Identifier struct {
+	expr    string
+	extract func(data interface{}) string
+}
+
+// ParseIdentifier parses an identifier expression. The expression is one of:
+//   - a preset name: "kubernetes" (or "k8s")
+//   - a dot path: "metadata.name"
+//   - comma-separated dot paths joined with "/": "kind,metadata.namespace,metadata.name"
+//   - a Go template: "{{.kind}}/{{.metadata.namespace}}/{{.metadata.name}}"
+func ParseIdentifier(expr string) (*Identifier, error) {
+	expr = strings.TrimSpace(expr)
+	id := &Identifier{expr: expr}
+
+	switch {
+	case expr == "":
+		return nil, fmt.Errorf("identifier expression is empty")
+	case expr == KubernetesKey || expr == "k8s":
+		id.extract = kubernetesKey
+	case strings.Contains(expr, "{{"):
+		extract, err := templateKey(expr)
+		if err != nil {
+			return nil, err
+		}
+		id.extract = extract
+	default:
+		var paths []string
+		for _, path := range strings.Split(expr, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				return nil, fmt.Errorf("empty path in identifier expression %q", expr)
+			}
+			paths = append(paths, path)
+		}
+		id.extract = func(data interface{}) string {
+			return compositeKey(data, paths)
+		}
+	}
+
+	return id, nil
+}
+
+// String returns the expression the identifier was parsed from
+func (id *Identifier) String() string {
+	return id.expr
+}
+
+// Extract returns the identifier of a document, or "" if it has none
+func (id *Identifier) Extract(data interface{}) string {
+	if data == nil {
+		return ""
+	}
+	return id.extract(data)
+}
+
+func kubernetesKey(data interface{}) string {
+	name := scalarString(lookupPath(data, "metadata.name"))
+	if name == "" {
+		return ""
+	}
+
+	var parts []string
+	if kind := scalarString(lookupPath(data, "kind")); kind != "" {
+		parts = append(parts, kind)
+	}
+	if namespace := scalarString(lookupPath(data, "metadata.namespace")); namespace != "" {
+		parts = append(parts, namespace)
+	}
+	return strings.Join(append(parts, name), "/")
+}
+
+// compositeKey joins the values at paths with "/". Missing values are left
+// empty; a document with none of the values has no identifier.
+func compositeKey(data interface{}, paths []string) string {
+	parts := make([]string, len(paths))
+	found := false
+	for i, path := range paths {
+		parts[i] = scalarString(lookupPath(data, path))
+		if parts[i] != "" {
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return strings.Join(parts, "/")
+}
+
+// templateKey builds an extractor from a Go template. Missing values render
+// as empty strings; a document for which the template renders the same as
+// for an empty document, or fails to render, has no identifier.
+func templateKey(expr string) (func(data interface{}) string, error) {
+	tmpl, err := template.New("key").Option("missingkey=zero").Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key template: %w", err)
+	}
+
+	render := func(data interface{}) (string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+	}
+
+	empty, _ := render(map[string]interface{}{})
+
+	return func(data interface{}) string {
+		key, err := render(data)
+		if err != nil || key == empty {
+			return ""
+		}
+		return key
+	}, nil
+}
+
+// scalarString formats a scalar value, returning "" for missing values,
+// mappings and sequences
+func scalarString(v interface{}) string {
+	switch val := v.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
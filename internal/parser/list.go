@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FlattenLists replaces Kubernetes List documents (kind List, or any kind
+// ending in List such as PodList, as written by kubectl get -o yaml) with
+// one document per item. Items of typed lists, which the API server returns
+// without kind and apiVersion, inherit them from the list.
+func FlattenLists(docs []Document) ([]Document, error) {
+	var out []Document
+	for _, doc := range docs {
+		items, ok := listItems(doc)
+		if !ok {
+			out = append(out, doc)
+			continue
+		}
+
+		list := doc.Content.(map[string]interface{})
+		itemKind := strings.TrimSuffix(scalarString(list["kind"]), "List")
+		itemNodes := mappingValue(doc.Node, "items")
+
+		for i, item := range items {
+			if m, ok := item.(map[string]interface{}); ok && itemKind != "" {
+				if _, ok := m["kind"]; !ok {
+					m["kind"] = itemKind
+				}
+				if _, ok := m["apiVersion"]; !ok && list["apiVersion"] != nil {
+					m["apiVersion"] = list["apiVersion"]
+				}
+			}
+
+			raw, err := yaml.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+
+			flat := doc
+			flat.Item = i + 1
+			flat.Content = item
+			flat.Raw = string(raw)
+			flat.Node = nil
+			if itemNodes != nil && itemNodes.Kind == yaml.SequenceNode && i < len(itemNodes.Content) {
+				flat.Node = itemNodes.Content[i]
+			}
+			out = append(out, flat)
+		}
+	}
+	return out, nil
+}
+
+// listItems returns the items of a List document
+func listItems(doc Document) ([]interface{}, bool) {
+	m, ok := doc.Content.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if kind, _ := m["kind"].(string); !strings.HasSuffix(kind, "List") {
+		return nil, false
+	}
+	items, ok := m["items"].([]interface{})
+	return items, ok
+}
+
+// mappingValue returns the value node of a key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenLists(t *testing.T) {
+	docs, err := ParseMultiDocYAMLBytes([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: before
+---
+apiVersion: apps/v1
+kind: DeploymentList
+items:
+- metadata:
+    name: web
+- kind: Other
+  apiVersion: v2
+  metadata:
+    name: explicit
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+- metadata:
+    name: bare
+---
+kind: Config
+items:
+- a
+`), "live.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flat, err := FlattenLists(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		Kind, APIVersion, Name string
+		Index, Item, Line      int
+	}
+	var got []item
+	for _, doc := range flat {
+		got = append(got, item{
+			Kind:       ExtractKey(doc.Content, "kind"),
+			APIVersion: ExtractKey(doc.Content, "apiVersion"),
+			Name:       ExtractKey(doc.Content, "metadata.name"),
+			Index:      doc.Index,
+			Item:       doc.Item,
+			Line:       doc.Position().Line,
+		})
+	}
+	want := []item{
+		{"ConfigMap", "v1", "before", 0, 0, 1},
+		{"Deployment", "apps/v1", "web", 1, 1, 9},
+		{"Other", "v2", "explicit", 1, 2, 11},
+		{"Service", "v1", "web", 2, 1, 19},
+		{"", "", "bare", 2, 2, 23},
+		{"Config", "", "", 3, 0, 26},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FlattenLists() =\n%+v\nwant\n%+v", got, want)
+	}
+	if flat[1].Raw != "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n    name: web\n" {
+		t.Errorf("Raw = %q", flat[1].Raw)
+	}
+}
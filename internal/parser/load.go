@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Stdin is the path that makes ParsePath read standard input
+const Stdin = "-"
+
+// StdinName labels documents read from standard input
+const StdinName = "<stdin>"
+
+// ParsePath parses every document of a single file, of all files of the
+// format (*.yaml and *.yml for FormatAuto) below a directory, or of all files
+// matching a glob pattern ("**" matches any number of directories). Documents
+// read from a directory or a glob carry their path relative to the directory
+// or the static part of the pattern in Source, so the same tree can be
+// compared across roots. Stdin reads standard input.
+func ParsePath(path string, format Format) ([]Document, error) {
+	if path == Stdin {
+		return ParseReader(os.Stdin, StdinName, format)
+	}
+
+	info, statErr := os.Stat(path)
+
+	switch {
+	case statErr == nil && info.IsDir():
+		files, err := filesIn(path, format)
+		if err != nil {
+			return nil, err
+		}
+		return parseFiles(path, files, format)
+	case statErr != nil && IsGlob(path):
+		root, files, err := expandGlob(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no files match %s: %w", path, fs.ErrNotExist)
+		}
+		return parseFiles(root, files, format)
+	default:
+		return ParseFile(path, format)
+	}
+}
+
+func parseFiles(root string, files []string, format Format) ([]Document, error) {
+	var docs []Document
+	for _, file := range files {
+		fileDocs, err := ParseFile(file, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		source, err := filepath.Rel(root, file)
+		if err != nil {
+			source = file
+		}
+		for i := range fileDocs {
+			fileDocs[i].Source = filepath.ToSlash(source)
+		}
+		docs = append(docs, fileDocs...)
+	}
+	return docs, nil
+}
+
+// filesIn returns the files of a format below dir in lexical order,
+// skipping hidden directories such as .git
+func filesIn(dir string, format Format) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if format.Matches(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// IsGlob reports whether a path contains glob wildcards
+func IsGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// GlobRoot returns the static directory prefix of a glob pattern, that is
+// everything before the first segment with a wildcard
+func GlobRoot(pattern string) string {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	var rootSegments []string
+	for _, seg := range strings.Split(pattern, "/") {
+		if IsGlob(seg) {
+			break
+		}
+		rootSegments = append(rootSegments, seg)
+	}
+	root := strings.Join(rootSegments, "/")
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+	return root
+}
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern
+// in which "**" matches any number of directories
+func MatchGlob(pattern, path string) bool {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	path = filepath.ToSlash(filepath.Clean(path))
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+// expandGlob returns the static directory prefix of a pattern and the files
+// below it that match the pattern
+func expandGlob(pattern string) (string, []string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return "", nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+		}
+	}
+
+	root := filepath.FromSlash(GlobRoot(pattern))
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && MatchGlob(pattern, path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	sort.Strings(files)
+	return root, files, nil
+}
+
+func matchGlobSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchGlobSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates files with the given contents below a temporary
+// directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGlobRoot(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{"manifests/**/*.yaml", "manifests"},
+		{"manifests/prod/*.yaml", "manifests/prod"},
+		{"./manifests/*/app.yaml", "manifests"},
+		{"*.yaml", "."},
+		{"**/*.yaml", "."},
+		{"/srv/manifests/[ab]*.yaml", "/srv/manifests"},
+		{"/*.yaml", "/"},
+	}
+	for _, tt := range tests {
+		if got := GlobRoot(tt.pattern); got != tt.want {
+			t.Errorf("GlobRoot(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.yaml", "app.yaml", true},
+		{"*.yaml", "app.yml", false},
+		{"*.yaml", "dir/app.yaml", false},
+		{"manifests/**/*.yaml", "manifests/app.yaml", true},
+		{"manifests/**/*.yaml", "manifests/prod/eu/app.yaml", true},
+		{"manifests/**/*.yaml", "other/app.yaml", false},
+		{"manifests/**", "manifests/prod/app.yaml", true},
+		{"**/app.yaml", "app.yaml", true},
+		{"**/app.yaml", "a/b/app.yaml", true},
+		{"manifests/*/app.yaml", "manifests/prod/eu/app.yaml", false},
+		{"manifests/?rod/*.y*ml", "manifests/prod/app.yml", true},
+		{"manifests/[ps]*/app.yaml", "manifests/staging/app.yaml", true},
+		{"./manifests/*.yaml", "manifests/app.yaml", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathTree(t *testing.T) {
+	doc := func(name string) string { return "kind: ConfigMap\nmetadata:\n  name: " + name + "\n" }
+	dir := writeTree(t, map[string]string{
+		"top.yaml":             doc("top"),
+		"prod/app.yml":         doc("app") + "---\n" + doc("app2"),
+		"prod/eu/db.yaml":      doc("db"),
+		"prod/notes.txt":       "not yaml",
+		".git/config.yaml":     doc("hidden"),
+		"prod/.cache/old.yaml": doc("cached"),
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"directory", dir, []string{"prod/app.yml", "prod/app.yml", "prod/eu/db.yaml", "top.yaml"}},
+		{"subdirectory", filepath.Join(dir, "prod"), []string{"app.yml", "app.yml", "eu/db.yaml"}},
+		{"glob", filepath.Join(dir, "prod", "*.yml"), []string{"app.yml", "app.yml"}},
+		{"double star", filepath.Join(dir, "prod", "**", "d*.yaml"), []string{"eu/db.yaml"}},
+		{"double star at the root", filepath.Join(dir, "**", "db.yaml"), []string{"prod/eu/db.yaml"}},
+		{"double star matching no directory", filepath.Join(dir, "**", "top.yaml"), []string{"top.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := ParsePath(tt.path, FormatAuto)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range docs {
+				got = append(got, d.Source)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sources = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	docs, err := ParsePath(filepath.Join(dir, "top.yaml"), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Source != "" {
+		t.Errorf("single file = %+v, want one document without Source", docs)
+	}
+}
+
+func TestParsePathMissing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.json"), []byte(`{"kind": "ConfigMap"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(dir, "app.yaml"),
+		filepath.Join(dir, "*.yaml"),
+		filepath.Join(dir, "manifests", "**", "*.yaml"),
+	} {
+		docs, err := ParsePath(path, FormatAuto)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ParsePath(%s) error = %v, want fs.ErrNotExist", path, err)
+		}
+		if len(docs) != 0 {
+			t.Errorf("ParsePath(%s) = %d documents, want none", path, len(docs))
+		}
+	}
+}
@@ -12,24 +12,100 @@ import (
 
 // Document represents a single YAML document
 type Document struct {
-	Content map[string]interface{}
+	// Content is the decoded root of the document: a mapping, a sequence,
+	// a scalar, or nil for an empty or null document
+	Content interface{}
 	Raw     string
 	Key     string
+	// File is the name of the file the document was read from
+	File string
+	// Source is the path of the file relative to the directory or glob it
+	// was found through, or empty for documents of a single file
+	Source string
+	// Index is the zero-based position of the document in its file
+	Index int
+	// Item is the one-based position of the document among the items of
+	// the List document it was flattened from, or 0 for other documents
+	Item int
+	// Node is the root node of the document, carrying source positions
+	Node *yaml.Node
+	// HeadComment and FootComment are the comments of the document itself,
+	// separated by a blank line from its first node or after its last
+	HeadComment string
+	FootComment string
+}
+
+// Position locates a document or a field in its source file
+type Position struct {
+	File     string
+	Document int
+	Line     int
+	Column   int
+}
+
+// IsZero reports whether the position is unknown
+func (p Position) IsZero() bool {
+	return p.Line == 0
+}
+
+// String formats the position as file:line:column
+func (p Position) String() string {
+	if p.IsZero() {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// IsEmpty reports whether the document has no content at all, as for a
+// bare "---". A document that is an explicit null is not empty.
+func (d Document) IsEmpty() bool {
+	if d.Node == nil {
+		return d.Content == nil
+	}
+	return (d.Node.Kind == yaml.ScalarNode && d.Node.Tag == "!!null" && d.Node.Value == "")
+}
+
+// Position returns the position of the document root
+func (d Document) Position() Position {
+	return d.NodePosition(d.Node)
+}
+
+// NodePosition returns the position of a node of the document
+func (d Document) NodePosition(node *yaml.Node) Position {
+	pos := Position{File: d.File, Document: d.Index}
+	if node != nil {
+		pos.Line = node.Line
+		pos.Column = node.Column
+	}
+	return pos
 }
 
 // ParseMultiDocYAML parses a YAML file that may contain multiple documents
 func ParseMultiDocYAML(filename string) ([]Document, error) {
-	data, err := os.ReadFile(filename)
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
+	return ParseMultiDocYAMLReader(f, filename)
+}
 
-	decoder := yaml.NewDecoder(bytes.NewReader(data))
+// ParseMultiDocYAMLBytes parses YAML that may contain multiple documents.
+// filename is only used to label the documents.
+func ParseMultiDocYAMLBytes(data []byte, filename string) ([]Document, error) {
+	return ParseMultiDocYAMLReader(bytes.NewReader(data), filename)
+}
+
+// ParseMultiDocYAMLReader parses YAML that may contain multiple documents
+// from a stream such as stdin. filename is only used to label the documents.
+func ParseMultiDocYAMLReader(r io.Reader, filename string) ([]Document, error) {
+	decoder := yaml.NewDecoder(r)
 	var docs []Document
 
 	for {
-		var doc map[string]interface{}
-		err := decoder.Decode(&doc)
+		// Decode into a node first to keep line and column information
+		var node yaml.Node
+		err := decoder.Decode(&node)
 		if err == io.EOF {
 			break
 		}
@@ -37,40 +113,67 @@ func ParseMultiDocYAML(filename string) ([]Document, error) {
 			return nil, err
 		}
 
-		// Marshal back to YAML for display
-		raw, err := yaml.Marshal(doc)
+		doc, err := newDocument(&node, filename, len(docs))
 		if err != nil {
 			return nil, err
 		}
-
-		docs = append(docs, Document{
-			Content: doc,
-			Raw:     string(raw),
-		})
+		docs = append(docs, doc)
 	}
 
 	return docs, nil
 }
 
-// ExtractKey extracts a value from a document using a dot-notation path
-func ExtractKey(data map[string]interface{}, path string) string {
-	keys := splitPath(path)
+// newDocument decodes a parsed document node
+func newDocument(node *yaml.Node, filename string, index int) (Document, error) {
+	var content interface{}
+	if err := node.Decode(&content); err != nil {
+		return Document{}, err
+	}
 
-	current := interface{}(data)
-	for _, key := range keys {
-		if m, ok := current.(map[string]interface{}); ok {
-			current = m[key]
-		} else {
-			return ""
+	// Marshal back to YAML for display
+	raw, err := yaml.Marshal(content)
+	if err != nil {
+		return Document{}, err
+	}
+
+	doc := Document{
+		Content: content,
+		Raw:     string(raw),
+		File:    filename,
+		Index:   index,
+		Node:    node,
+	}
+	if node.Kind == yaml.DocumentNode {
+		doc.HeadComment, doc.FootComment = node.HeadComment, node.FootComment
+		if len(node.Content) > 0 {
+			doc.Node = node.Content[0]
 		}
 	}
+	return doc, nil
+}
 
-	if str, ok := current.(string); ok {
+// ExtractKey extracts a value from a document using a dot-notation path
+func ExtractKey(data interface{}, path string) string {
+	if str, ok := lookupPath(data, path).(string); ok {
 		return str
 	}
 	return ""
 }
 
+// lookupPath returns the value at a dot-notation path, or nil if any
+// segment is missing or the document is not a mapping
+func lookupPath(data interface{}, path string) interface{} {
+	current := data
+	for _, key := range splitPath(path) {
+		if m, ok := current.(map[string]interface{}); ok {
+			current = m[key]
+		} else {
+			return nil
+		}
+	}
+	return current
+}
+
 func splitPath(path string) []string {
 	var result []string
 	var current string
@@ -114,45 +217,3 @@ func SplitLines(text string) []string {
 	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
 	return lines
 }
-
-// CompareValues recursively compares two values and returns a formatted diff
-func CompareValues(path string, oldVal, newVal interface{}) []string {
-	var diffs []string
-
-	oldMap, oldIsMap := oldVal.(map[string]interface{})
-	newMap, newIsMap := newVal.(map[string]interface{})
-
-	if oldIsMap && newIsMap {
-		// Both are maps - recurse
-		allKeys := make(map[string]bool)
-		for k := range oldMap {
-			allKeys[k] = true
-		}
-		for k := range newMap {
-			allKeys[k] = true
-		}
-
-		for key := range allKeys {
-			newPath := path + "." + key
-			if path == "" {
-				newPath = key
-			}
-
-			oldV, oldExists := oldMap[key]
-			newV, newExists := newMap[key]
-
-			if !oldExists && newExists {
-				diffs = append(diffs, fmt.Sprintf("+ %s: %v", newPath, newV))
-			} else if oldExists && !newExists {
-				diffs = append(diffs, fmt.Sprintf("- %s: %v", newPath, oldV))
-			} else if oldExists && newExists {
-				subDiffs := CompareValues(newPath, oldV, newV)
-				diffs = append(diffs, subDiffs...)
-			}
-		}
-	} else if fmt.Sprintf("%v", oldVal) != fmt.Sprintf("%v", newVal) {
-		diffs = append(diffs, fmt.Sprintf("~ %s: %v → %v", path, oldVal, newVal))
-	}
-
-	return diffs
-}
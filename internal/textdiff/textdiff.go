@@ -0,0 +1,268 @@
+// Package textdiff computes line-based diffs and groups them into hunks
+// with context, as in unified diff output.
+package textdiff
+
+import "fmt"
+
+// Kind tells whether a line is shared, removed or inserted
+type Kind int
+
+const (
+	// Equal lines exist in both texts
+	Equal Kind = iota
+	// Delete lines only exist in the old text
+	Delete
+	// Insert lines only exist in the new text
+	Insert
+)
+
+// Line is one line of a diff
+type Line struct {
+	Kind Kind
+	Text string
+	// OldLine and NewLine are one-based line numbers, 0 on the side where
+	// the line does not exist
+	OldLine int
+	NewLine int
+}
+
+// Hunk is a run of changed lines with surrounding context
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the unified diff hunk header, e.g. "@@ -3,7 +3,8 @@"
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// MaxEdits caps the number of deleted and inserted lines Diff searches for.
+// The search keeps O(D²) state for an edit script of D lines, so beyond the
+// cap the differing middle of the texts is reported as deleted and inserted
+// wholesale instead.
+const MaxEdits = 2000
+
+// Diff returns the lines of a shortest edit script turning a into b, using
+// Myers' algorithm. Deletions come before insertions within a change. The
+// common prefix and suffix are matched first, and the rest falls back to
+// delete-all/insert-all when it needs more than MaxEdits edits.
+func Diff(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, Line{Kind: Equal, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	middle, ok := myers(midA, midB, MaxEdits)
+	if !ok {
+		middle = replaceAll(midA, midB)
+	}
+	for _, line := range middle {
+		if line.OldLine > 0 {
+			line.OldLine += prefix
+		}
+		if line.NewLine > 0 {
+			line.NewLine += prefix
+		}
+		lines = append(lines, line)
+	}
+
+	for i := suffix; i > 0; i-- {
+		oldIndex, newIndex := len(a)-i, len(b)-i
+		lines = append(lines, Line{Kind: Equal, Text: a[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+	return lines
+}
+
+// myers returns the shortest edit script turning a into b, or false if it
+// is longer than maxEdits lines
+func myers(a, b []string, maxEdits int) ([]Line, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b), true
+	}
+	total := n + m
+	offset := total + 1
+	v := make([]int, 2*total+2)
+	// trace[d] holds diagonals -d..d of v as they were before step d
+	var trace [][]int
+
+	// Forward pass: find the length of the shortest edit script and keep
+	// the furthest reaching paths of every step
+	found := false
+	for d := 0; d <= total && !found; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Backward pass: walk the trace from the end to recover the edits
+	var reversed []Line
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		var prevX int
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Line{Kind: Equal, Text: a[x], OldLine: x + 1, NewLine: y + 1})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, Line{Kind: Insert, Text: b[y], NewLine: y + 1})
+		} else {
+			x--
+			reversed = append(reversed, Line{Kind: Delete, Text: a[x], OldLine: x + 1})
+		}
+	}
+
+	lines := make([]Line, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines, true
+}
+
+// replaceAll returns a diff deleting every line of a and inserting every
+// line of b
+func replaceAll(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	for i, text := range a {
+		lines = append(lines, Line{Kind: Delete, Text: text, OldLine: i + 1})
+	}
+	for i, text := range b {
+		lines = append(lines, Line{Kind: Insert, Text: text, NewLine: i + 1})
+	}
+	return lines
+}
+
+// Hunks groups the changed lines of a diff into hunks with up to context
+// unchanged lines before and after each change. Changes separated by at
+// most 2*context unchanged lines share a hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk while the next change is close enough
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Kind == Equal {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk builds the hunk of lines[start:end]
+func newHunk(lines []Line, start, end int) Hunk {
+	h := Hunk{Lines: lines[start:end]}
+
+	// Count the lines of each side before the hunk
+	for _, line := range lines[:start] {
+		if line.Kind != Insert {
+			h.OldStart++
+		}
+		if line.Kind != Delete {
+			h.NewStart++
+		}
+	}
+	for _, line := range h.Lines {
+		if line.Kind != Insert {
+			h.OldLines++
+		}
+		if line.Kind != Delete {
+			h.NewLines++
+		}
+	}
+
+	// A side without lines starts at the line before the hunk, as in
+	// unified diffs
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
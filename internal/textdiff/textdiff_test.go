@@ -0,0 +1,146 @@
+package textdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render writes a diff in the compact form " a", "-b", "+c"
+func render(lines []Line) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		prefix := map[Kind]string{Equal: " ", Delete: "-", Insert: "+"}[line.Kind]
+		out = append(out, prefix+line.Text)
+	}
+	return out
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{"both empty", "", "", []string{}},
+		{"old empty", "", "a,b", []string{"+a", "+b"}},
+		{"new empty", "a,b", "", []string{"-a", "-b"}},
+		{"equal", "a,b", "a,b", []string{" a", " b"}},
+		{"replace middle", "a,b,c", "a,x,c", []string{" a", "-b", "+x", " c"}},
+		{"insert", "a,c", "a,b,c", []string{" a", "+b", " c"}},
+		{"delete", "a,b,c", "a,c", []string{" a", "-b", " c"}},
+		{"replace all", "a,b", "c,d", []string{"-a", "-b", "+c", "+d"}},
+		{"moved line", "a,b,c", "b,c,a", []string{"-a", " b", " c", "+a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(Diff(split(tt.a), split(tt.b)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLineNumbers(t *testing.T) {
+	lines := Diff(split("a,b,c,d"), split("a,x,c,d,e"))
+	want := []Line{
+		{Kind: Equal, Text: "a", OldLine: 1, NewLine: 1},
+		{Kind: Delete, Text: "b", OldLine: 2},
+		{Kind: Insert, Text: "x", NewLine: 2},
+		{Kind: Equal, Text: "c", OldLine: 3, NewLine: 3},
+		{Kind: Equal, Text: "d", OldLine: 4, NewLine: 4},
+		{Kind: Insert, Text: "e", NewLine: 5},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Diff() = %+v, want %+v", lines, want)
+	}
+}
+
+func TestDiffFallback(t *testing.T) {
+	// More than MaxEdits differing lines between a shared prefix and suffix
+	var a, b []string
+	a = append(a, "head")
+	b = append(b, "head")
+	for i := 0; i < MaxEdits; i++ {
+		a = append(a, fmt.Sprintf("old %d", i))
+		b = append(b, fmt.Sprintf("new %d", i))
+	}
+	a = append(a, "tail")
+	b = append(b, "tail")
+
+	lines := Diff(a, b)
+	if len(lines) != 2+2*MaxEdits {
+		t.Fatalf("got %d lines, want %d", len(lines), 2+2*MaxEdits)
+	}
+	if lines[0].Kind != Equal || lines[len(lines)-1].Kind != Equal {
+		t.Errorf("prefix and suffix should stay equal")
+	}
+	for i, line := range lines[1 : 1+MaxEdits] {
+		if line.Kind != Delete || line.OldLine != i+2 {
+			t.Fatalf("line %d = %+v, want deletion of old line %d", i+1, line, i+2)
+		}
+	}
+	for i, line := range lines[1+MaxEdits : 1+2*MaxEdits] {
+		if line.Kind != Insert || line.NewLine != i+2 {
+			t.Fatalf("line %d = %+v, want insertion of new line %d", i+1+MaxEdits, line, i+2)
+		}
+	}
+	if last := lines[len(lines)-1]; last.OldLine != len(a) || last.NewLine != len(b) {
+		t.Errorf("suffix line numbers = %d/%d, want %d/%d", last.OldLine, last.NewLine, len(a), len(b))
+	}
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []string
+	}{
+		{"no changes", "a,b", "a,b", 3, nil},
+		{"both empty", "", "", 3, nil},
+		{"old empty", "", "a,b", 3, []string{"@@ -0,0 +1,2 @@"}},
+		{"new empty", "a", "", 3, []string{"@@ -1 +0,0 @@"}},
+		{"insert without context", "a,b", "a,x,b", 0, []string{"@@ -1,0 +2 @@"}},
+		{"delete without context", "a,x,b", "a,b", 0, []string{"@@ -2 +1,0 @@"}},
+		{"context clipped at edges", "a,b,c", "a,x,c", 3, []string{"@@ -1,3 +1,3 @@"}},
+		// Changes 2*context unchanged lines apart share a hunk, one more
+		// line splits them
+		{"merged at 2*context", "x,a,b,y", "X,a,b,Y", 1, []string{"@@ -1,4 +1,4 @@"}},
+		{"split above 2*context", "x,a,b,c,y", "X,a,b,c,Y", 1, []string{"@@ -1,2 +1,2 @@", "@@ -4,2 +4,2 @@"}},
+		{"negative context", "a,b,c", "a,x,c", -1, []string{"@@ -2 +2 @@"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hunk := range Hunks(Diff(split(tt.a), split(tt.b)), tt.context) {
+				got = append(got, hunk.Header())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hunks() headers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHunkLines(t *testing.T) {
+	hunks := Hunks(Diff(split("a,b,c,d,e,f,g"), split("a,b,c,X,e,f,g")), 1)
+	if len(hunks) != 1 {
+		t.Fatalf("got %d hunks, want 1", len(hunks))
+	}
+	want := []string{" c", "-d", "+X", " e"}
+	if got := render(hunks[0].Lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("hunk lines = %q, want %q", got, want)
+	}
+}